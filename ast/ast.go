@@ -9,6 +9,8 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // 节点第一个字符的位置
+	End() token.Position // 节点最后一个字符之后的位置
 }
 
 type Statement interface {
@@ -23,6 +25,7 @@ type Expression interface {
 
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // 源代码中的注释，只有词法分析器开启 ScanComments 模式时才会记录
 }
 
 func (p *Program) TokenLiteral() string {
@@ -33,6 +36,20 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if n := len(p.Statements); n > 0 {
+		return p.Statements[n-1].End()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -54,6 +71,9 @@ func (i *Identifer) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *Identifer) Pos() token.Position { return i.Token.Pos }
+func (i *Identifer) End() token.Position { return i.Token.End() }
+
 func (i *Identifer) String() string {
 	return i.Value
 }
@@ -69,6 +89,39 @@ func (s *StringLiteral) TokenLiteral() string {
 	return s.Token.Literal
 }
 
+func (s *StringLiteral) Pos() token.Position { return s.Token.Pos }
+func (s *StringLiteral) End() token.Position { return s.Token.End() }
+
 func (s *StringLiteral) String() string {
 	return s.Token.Literal
 }
+
+// InterpolatedString 带插值的字符串 "a ${x} b"，Strings 比 Parts 多一个元素，
+// 二者交替拼接得到字符串的值
+type InterpolatedString struct {
+	Token   token.Token // INTERP_HEAD 词法单元
+	Strings []string
+	Parts   []Expression
+	Tail    token.Token // INTERP_TAIL 词法单元
+}
+
+func (is *InterpolatedString) expressionNode() {}
+
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *InterpolatedString) Pos() token.Position { return is.Token.Pos }
+func (is *InterpolatedString) End() token.Position { return is.Tail.End() }
+
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	for i, p := range is.Parts {
+		out.WriteString(is.Strings[i])
+		out.WriteString("${")
+		out.WriteString(p.String())
+		out.WriteString("}")
+	}
+	out.WriteString(is.Strings[len(is.Strings)-1])
+	return out.String()
+}
@@ -0,0 +1,32 @@
+package ast
+
+import "github.com/fengshux/monkey/token"
+
+// BadExpression 是语法错误处的占位表达式，From 和 To 是出错的源代码范围。
+// 与缺失的表达式一样，String() 返回空字符串
+type BadExpression struct {
+	From, To token.Position
+}
+
+func (be *BadExpression) expressionNode() {}
+
+func (be *BadExpression) TokenLiteral() string { return "" }
+
+func (be *BadExpression) String() string { return "" }
+
+func (be *BadExpression) Pos() token.Position { return be.From }
+func (be *BadExpression) End() token.Position { return be.To }
+
+// BadStatement 是语法错误处的占位语句，From 和 To 是被跳过的源代码范围，String() 同样返回空字符串
+type BadStatement struct {
+	From, To token.Position
+}
+
+func (bs *BadStatement) statementNode() {}
+
+func (bs *BadStatement) TokenLiteral() string { return "" }
+
+func (bs *BadStatement) String() string { return "" }
+
+func (bs *BadStatement) Pos() token.Position { return bs.From }
+func (bs *BadStatement) End() token.Position { return bs.To }
@@ -8,10 +8,14 @@ import (
 )
 
 type ArrayLiteral struct {
-	Token    token.Token
+	Token    token.Token // [ 词法单元
 	Elements []Expression
+	Rbracket token.Token // ] 词法单元
 }
 
+func (a *ArrayLiteral) Pos() token.Position { return a.Token.Pos }
+func (a *ArrayLiteral) End() token.Position { return a.Rbracket.End() }
+
 func (a *ArrayLiteral) expressionNode() {}
 
 func (a *ArrayLiteral) TokenLiteral() string {
@@ -33,11 +37,21 @@ func (a *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token // [ 词法单元
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // ] 词法单元
 }
 
+func (i *IndexExpression) Pos() token.Position {
+	if i.Left != nil {
+		return i.Left.Pos()
+	}
+	return i.Token.Pos
+}
+
+func (i *IndexExpression) End() token.Position { return i.Rbracket.End() }
+
 func (i *IndexExpression) expressionNode() {}
 
 func (i *IndexExpression) TokenLiteral() string {
@@ -56,11 +70,41 @@ func (i *IndexExpression) String() string {
 	return out.String()
 }
 
+// MemberExpression Object.Property，访问哈希的字段、模块导出的名字或者内置类型的方法
+type MemberExpression struct {
+	Token    token.Token // . 词法单元
+	Object   Expression
+	Property *Identifer
+}
+
+func (m *MemberExpression) Pos() token.Position {
+	if m.Object != nil {
+		return m.Object.Pos()
+	}
+	return m.Token.Pos
+}
+
+func (m *MemberExpression) End() token.Position { return m.Property.End() }
+
+func (m *MemberExpression) expressionNode() {}
+
+func (m *MemberExpression) TokenLiteral() string {
+	return m.Token.Literal
+}
+
+func (m *MemberExpression) String() string {
+	return "(" + m.Object.String() + "." + m.Property.String() + ")"
+}
+
 type HashLiteral struct {
-	Token token.Token
-	Pairs map[Expression]Expression
+	Token  token.Token // { 词法单元
+	Pairs  map[Expression]Expression
+	Rbrace token.Token // } 词法单元
 }
 
+func (h *HashLiteral) Pos() token.Position { return h.Token.Pos }
+func (h *HashLiteral) End() token.Position { return h.Rbrace.End() }
+
 func (h *HashLiteral) expressionNode() {}
 
 func (h *HashLiteral) TokenLiteral() string {
@@ -0,0 +1,19 @@
+package ast
+
+import "github.com/fengshux/monkey/token"
+
+// Comment 表示一个 // 或 /* */ 注释，Token.Literal 包含注释符号本身
+type Comment struct {
+	Token token.Token
+}
+
+func (c *Comment) TokenLiteral() string {
+	return c.Token.Literal
+}
+
+func (c *Comment) String() string {
+	return c.Token.Literal
+}
+
+func (c *Comment) Pos() token.Position { return c.Token.Pos }
+func (c *Comment) End() token.Position { return c.Token.End() }
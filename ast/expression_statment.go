@@ -18,6 +18,20 @@ func (e *ExpressionStatement) TokenLiteral() string {
 	return e.Token.Literal
 }
 
+func (e *ExpressionStatement) Pos() token.Position {
+	if e.Expression != nil {
+		return e.Expression.Pos()
+	}
+	return e.Token.Pos
+}
+
+func (e *ExpressionStatement) End() token.Position {
+	if e.Expression != nil {
+		return e.Expression.End()
+	}
+	return e.Token.End()
+}
+
 func (e *ExpressionStatement) String() string {
 	if e.Expression != nil {
 		return e.Expression.String()
@@ -36,10 +50,31 @@ func (i *IntegerLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *IntegerLiteral) Pos() token.Position { return i.Token.Pos }
+func (i *IntegerLiteral) End() token.Position { return i.Token.End() }
+
 func (i *IntegerLiteral) String() string {
 	return i.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) expressionNode() {}
+
+func (f *FloatLiteral) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FloatLiteral) Pos() token.Position { return f.Token.Pos }
+func (f *FloatLiteral) End() token.Position { return f.Token.End() }
+
+func (f *FloatLiteral) String() string {
+	return f.Token.Literal
+}
+
 type PrefixExpression struct {
 	Token    token.Token // 前缀词法单元
 	Operator string
@@ -52,6 +87,15 @@ func (p *PrefixExpression) TokenLiteral() string {
 	return p.Token.Literal
 }
 
+func (p *PrefixExpression) Pos() token.Position { return p.Token.Pos }
+
+func (p *PrefixExpression) End() token.Position {
+	if p.Right != nil {
+		return p.Right.End()
+	}
+	return p.Token.End()
+}
+
 func (p *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -76,6 +120,20 @@ func (i InfixExpression) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i InfixExpression) Pos() token.Position {
+	if i.Left != nil {
+		return i.Left.Pos()
+	}
+	return i.Token.Pos
+}
+
+func (i InfixExpression) End() token.Position {
+	if i.Right != nil {
+		return i.Right.End()
+	}
+	return i.Token.End()
+}
+
 func (i InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -88,6 +146,32 @@ func (i InfixExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression 赋值表达式 Target = Value，Target 是标识符或者索引表达式
+type AssignExpression struct {
+	Token  token.Token // = 词法单元
+	Target Expression
+	Value  Expression
+}
+
+func (a *AssignExpression) expressionNode() {}
+
+func (a *AssignExpression) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *AssignExpression) Pos() token.Position { return a.Target.Pos() }
+
+func (a *AssignExpression) End() token.Position {
+	if a.Value != nil {
+		return a.Value.End()
+	}
+	return a.Token.End()
+}
+
+func (a *AssignExpression) String() string {
+	return a.Target.String() + " = " + a.Value.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -99,10 +183,30 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position { return b.Token.Pos }
+func (b *Boolean) End() token.Position { return b.Token.End() }
+
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (n *NullLiteral) expressionNode() {}
+
+func (n *NullLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+
+func (n *NullLiteral) Pos() token.Position { return n.Token.Pos }
+func (n *NullLiteral) End() token.Position { return n.Token.End() }
+
+func (n *NullLiteral) String() string {
+	return n.Token.Literal
+}
+
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -116,6 +220,18 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
+
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End()
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -131,10 +247,14 @@ func (ie *IfExpression) String() string {
 }
 
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // { 词法单元
 	Statements []Statement
+	Rbrace     token.Token // } 词法单元
 }
 
+func (b *BlockStatement) Pos() token.Position { return b.Token.Pos }
+func (b *BlockStatement) End() token.Position { return b.Rbrace.End() }
+
 func (b *BlockStatement) statementNode() {}
 
 func (b *BlockStatement) TokenLiteral() string {
@@ -152,38 +272,103 @@ func (b *BlockStatement) String() string {
 
 type FunctionLiteral struct {
 	Token      token.Token
-	Parameters []*Identifer
+	Parameters []*Parameter
+	Rest       *Identifer // 剩余参数 ...rest，接收多余的位置实参
 	Body       *BlockStatement
 }
 
+// Parameter 函数的形参，普通形参只有 Name，解构的形参只有 Pattern
+type Parameter struct {
+	Name    *Identifer
+	Pattern Expression // 解构模式 [a, b] 或 {c}
+	Default Expression // 默认值，没有默认值时为nil
+}
+
+func (p *Parameter) Pos() token.Position {
+	if p.Pattern != nil {
+		return p.Pattern.Pos()
+	}
+	return p.Name.Pos()
+}
+
+func (p *Parameter) String() string {
+	s := ""
+	if p.Pattern != nil {
+		s = p.Pattern.String()
+	} else {
+		s = p.Name.String()
+	}
+	if p.Default != nil {
+		s += " = " + p.Default.String()
+	}
+	return s
+}
+
 func (f *FunctionLiteral) expressionNode() {}
 
 func (f *FunctionLiteral) TokenLiteral() string {
 	return f.Token.Literal
 }
 
-func (f *FunctionLiteral) String() string {
-	var out bytes.Buffer
+func (f *FunctionLiteral) Pos() token.Position { return f.Token.Pos }
 
-	param := []string{}
-	for _, p := range f.Parameters {
-		param = append(param, p.String())
+func (f *FunctionLiteral) End() token.Position {
+	if f.Body != nil {
+		return f.Body.End()
 	}
+	return f.Token.End()
+}
+
+func (f *FunctionLiteral) String() string {
+	var out bytes.Buffer
 
 	out.WriteString(f.TokenLiteral())
 	out.WriteString("(")
-	out.WriteString(strings.Join(param, ", "))
+	out.WriteString(ParametersString(f.Parameters, f.Rest))
 	out.WriteString(")")
 	out.WriteString(f.Body.String())
 	return out.String()
 }
 
+// ParametersString 返回形参列表的字符串形式，如 x, [a, b], y = 2, ...rest
+func ParametersString(params []*Parameter, rest *Identifer) string {
+	param := []string{}
+	for _, p := range params {
+		param = append(param, p.String())
+	}
+	if rest != nil {
+		param = append(param, "..."+rest.String())
+	}
+	return strings.Join(param, ", ")
+}
+
 type CallExpression struct {
-	Token     token.Token
+	Token     token.Token // ( 词法单元
 	Function  Expression
-	Arguments []Expression
+	Arguments []Expression       // 位置实参
+	Keywords  []*KeywordArgument // 命名实参 name: value，位于位置实参之后
+	Rparen    token.Token        // ) 词法单元
+}
+
+// KeywordArgument 调用时的命名实参 name: value
+type KeywordArgument struct {
+	Name  *Identifer
+	Value Expression
+}
+
+func (k *KeywordArgument) String() string {
+	return k.Name.String() + ": " + k.Value.String()
 }
 
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+
+func (ce *CallExpression) End() token.Position { return ce.Rparen.End() }
+
 func (ce *CallExpression) expressionNode() {}
 
 func (ce *CallExpression) TokenLiteral() string {
@@ -197,6 +382,9 @@ func (ce *CallExpression) String() string {
 	for _, a := range ce.Arguments {
 		args = append(args, a.String())
 	}
+	for _, k := range ce.Keywords {
+		args = append(args, k.String())
+	}
 
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
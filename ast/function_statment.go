@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// FunctionStatement 具名函数声明 fn name(params) { body }，
+// 声明在所在的语句块开始执行之前就已经绑定
+type FunctionStatement struct {
+	Token    token.Token // fn 词法单元
+	Name     *Identifer
+	Function *FunctionLiteral
+}
+
+func (f *FunctionStatement) statementNode() {}
+
+func (f *FunctionStatement) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FunctionStatement) Pos() token.Position { return f.Token.Pos }
+
+func (f *FunctionStatement) End() token.Position {
+	if f.Function != nil {
+		return f.Function.End()
+	}
+	return f.Name.End()
+}
+
+func (f *FunctionStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(f.TokenLiteral() + " ")
+	out.WriteString(f.Name.String())
+	out.WriteString("(")
+	out.WriteString(ParametersString(f.Function.Parameters, f.Function.Rest))
+	out.WriteString(")")
+	out.WriteString(f.Function.Body.String())
+	return out.String()
+}
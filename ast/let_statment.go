@@ -7,9 +7,10 @@ import (
 )
 
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifer
-	Value Expression
+	Token   token.Token
+	Name    *Identifer
+	Pattern Expression // 解构模式 [a, b] 或 {name}，此时 Name 为nil
+	Value   Expression
 }
 
 func (l *LetStatement) statementNode() {}
@@ -18,10 +19,29 @@ func (l *LetStatement) TokenLiteral() string {
 	return l.Token.Literal
 }
 
+func (l *LetStatement) Pos() token.Position { return l.Token.Pos }
+
+func (l *LetStatement) End() token.Position {
+	if l.Value != nil {
+		return l.Value.End()
+	}
+	if l.Name != nil {
+		return l.Name.End()
+	}
+	if l.Pattern != nil {
+		return l.Pattern.End()
+	}
+	return l.Token.End()
+}
+
 func (l *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(l.TokenLiteral() + " ")
-	out.WriteString(l.Name.String())
+	if l.Name != nil {
+		out.WriteString(l.Name.String())
+	} else {
+		out.WriteString(l.Pattern.String())
+	}
 	out.WriteString(" = ")
 	if l.Value != nil {
 		out.WriteString(l.Value.String())
@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// WhileStatement while (Condition) { Body }
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (w *WhileStatement) statementNode() {}
+
+func (w *WhileStatement) TokenLiteral() string {
+	return w.Token.Literal
+}
+
+func (w *WhileStatement) Pos() token.Position { return w.Token.Pos }
+
+func (w *WhileStatement) End() token.Position {
+	if w.Body != nil {
+		return w.Body.End()
+	}
+	return w.Token.End()
+}
+
+func (w *WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("while (")
+	out.WriteString(w.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(w.Body.String())
+	return out.String()
+}
+
+// ForInStatement for (Variable in Iterable) { Body }
+type ForInStatement struct {
+	Token    token.Token
+	Variable *Identifer
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (f *ForInStatement) statementNode() {}
+
+func (f *ForInStatement) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *ForInStatement) Pos() token.Position { return f.Token.Pos }
+
+func (f *ForInStatement) End() token.Position {
+	if f.Body != nil {
+		return f.Body.End()
+	}
+	return f.Token.End()
+}
+
+func (f *ForInStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("for (")
+	out.WriteString(f.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(f.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(f.Body.String())
+	return out.String()
+}
+
+// BranchStatement 是 break 或 continue 语句，Token 区分二者
+type BranchStatement struct {
+	Token token.Token
+}
+
+func (b *BranchStatement) statementNode() {}
+
+func (b *BranchStatement) TokenLiteral() string {
+	return b.Token.Literal
+}
+
+func (b *BranchStatement) Pos() token.Position { return b.Token.Pos }
+func (b *BranchStatement) End() token.Position { return b.Token.End() }
+
+func (b *BranchStatement) String() string {
+	return b.Token.Literal + ";"
+}
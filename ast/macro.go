@@ -13,6 +13,15 @@ type MacroLiteral struct {
 	Body       *BlockStatement
 }
 
+func (m *MacroLiteral) Pos() token.Position { return m.Token.Pos }
+
+func (m *MacroLiteral) End() token.Position {
+	if m.Body != nil {
+		return m.Body.End()
+	}
+	return m.Token.End()
+}
+
 func (m *MacroLiteral) expressionNode() {}
 
 func (m *MacroLiteral) TokenLiteral() string {
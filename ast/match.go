@@ -0,0 +1,80 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// MatchExpression match (Subject) { pattern if guard => body, ... }，
+// 按顺序尝试每个分支，返回第一个匹配的分支的值
+type MatchExpression struct {
+	Token   token.Token // match 词法单元
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Token
+}
+
+// MatchArm match 的一个分支，Guard 可以为nil
+type MatchArm struct {
+	Pattern Expression
+	Guard   Expression
+	Body    Expression
+}
+
+func (a *MatchArm) String() string {
+	var out bytes.Buffer
+	out.WriteString(a.Pattern.String())
+	if a.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(a.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(a.Body.String())
+	return out.String()
+}
+
+func (m *MatchExpression) expressionNode() {}
+
+func (m *MatchExpression) TokenLiteral() string {
+	return m.Token.Literal
+}
+
+func (m *MatchExpression) Pos() token.Position { return m.Token.Pos }
+func (m *MatchExpression) End() token.Position { return m.Rbrace.End() }
+
+func (m *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, a := range m.Arms {
+		arms = append(arms, a.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(m.Subject.String())
+	out.WriteString(") {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// TypePattern 类型测试模式 pattern: TYPE，值的类型是 Type 时再匹配 Pattern
+type TypePattern struct {
+	Pattern Expression
+	Type    *Identifer // 类型名，如 INTEGER、STRING
+}
+
+func (tp *TypePattern) expressionNode() {}
+
+func (tp *TypePattern) TokenLiteral() string {
+	return tp.Type.TokenLiteral()
+}
+
+func (tp *TypePattern) Pos() token.Position { return tp.Pattern.Pos() }
+func (tp *TypePattern) End() token.Position { return tp.Type.End() }
+
+func (tp *TypePattern) String() string {
+	return tp.Pattern.String() + ": " + tp.Type.String()
+}
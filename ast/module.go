@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"github.com/fengshux/monkey/token"
+)
+
+// ImportExpression import "path"，值为加载后的模块
+type ImportExpression struct {
+	Token token.Token
+	Path  *StringLiteral
+}
+
+func (i *ImportExpression) expressionNode() {}
+
+func (i *ImportExpression) TokenLiteral() string {
+	return i.Token.Literal
+}
+
+func (i *ImportExpression) Pos() token.Position { return i.Token.Pos }
+func (i *ImportExpression) End() token.Position { return i.Path.End() }
+
+func (i *ImportExpression) String() string {
+	return "import \"" + i.Path.Value + "\""
+}
+
+// ExportStatement 导出顶层的 let、const 或具名函数声明所绑定的名字
+type ExportStatement struct {
+	Token     token.Token
+	Statement Statement // *LetStatement 或 *FunctionStatement
+}
+
+func (e *ExportStatement) statementNode() {}
+
+func (e *ExportStatement) TokenLiteral() string {
+	return e.Token.Literal
+}
+
+func (e *ExportStatement) Pos() token.Position { return e.Token.Pos }
+func (e *ExportStatement) End() token.Position { return e.Statement.End() }
+
+func (e *ExportStatement) String() string {
+	return "export " + e.Statement.String()
+}
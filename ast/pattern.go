@@ -0,0 +1,115 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// ArrayPattern 数组解构模式 [a, [b, c], ...rest]，元素是标识符或嵌套的模式
+type ArrayPattern struct {
+	Token    token.Token // [ 词法单元
+	Elements []Expression
+	Rest     *Identifer // 接收剩余元素，可以为nil
+	Rbracket token.Token
+}
+
+func (ap *ArrayPattern) expressionNode() {}
+
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+
+func (ap *ArrayPattern) Pos() token.Position { return ap.Token.Pos }
+func (ap *ArrayPattern) End() token.Position { return ap.Rbracket.End() }
+
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+// HashPattern 哈希解构模式 {name, "first-name": first, age: years}，
+// Keys 是标识符或字符串字面量，Values 是对应的绑定目标
+type HashPattern struct {
+	Token  token.Token // { 词法单元
+	Keys   []Expression
+	Values []Expression
+	Rbrace token.Token
+}
+
+func (hp *HashPattern) expressionNode() {}
+
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+
+func (hp *HashPattern) Pos() token.Position { return hp.Token.Pos }
+func (hp *HashPattern) End() token.Position { return hp.Rbrace.End() }
+
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for i, key := range hp.Keys {
+		if ident, ok := key.(*Identifer); ok {
+			if value, ok := hp.Values[i].(*Identifer); ok && value.Value == ident.Value {
+				pairs = append(pairs, ident.String())
+				continue
+			}
+		}
+		pairs = append(pairs, key.String()+": "+hp.Values[i].String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// PatternKey 返回哈希模式中键的名字
+func PatternKey(key Expression) string {
+	switch key := key.(type) {
+	case *Identifer:
+		return key.Value
+	case *StringLiteral:
+		return key.Value
+	}
+	return key.String()
+}
+
+// PatternNames 按出现的顺序返回解构模式绑定的标识符，pattern 本身是标识符时返回它自己
+func PatternNames(pattern Expression) []*Identifer {
+	return appendPatternNames(nil, pattern)
+}
+
+func appendPatternNames(names []*Identifer, pattern Expression) []*Identifer {
+	switch pattern := pattern.(type) {
+	case *Identifer:
+		names = append(names, pattern)
+	case *ArrayPattern:
+		for _, el := range pattern.Elements {
+			names = appendPatternNames(names, el)
+		}
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+	case *HashPattern:
+		for _, v := range pattern.Values {
+			names = appendPatternNames(names, v)
+		}
+	}
+	return names
+}
@@ -17,6 +17,15 @@ func (r *ReturnStatment) TokenLiteral() string {
 	return r.Token.Literal
 }
 
+func (r *ReturnStatment) Pos() token.Position { return r.Token.Pos }
+
+func (r *ReturnStatment) End() token.Position {
+	if r.ReturnValue != nil {
+		return r.ReturnValue.End()
+	}
+	return r.Token.End()
+}
+
 func (r *ReturnStatment) String() string {
 	var out bytes.Buffer
 	out.WriteString(r.TokenLiteral() + " ")
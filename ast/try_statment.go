@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// TryStatement try { Block } catch (Param) { Catch } finally { Finally }，
+// Catch 和 Finally 至少有一个不为 nil
+type TryStatement struct {
+	Token   token.Token
+	Block   *BlockStatement
+	Param   *Identifer
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (t *TryStatement) statementNode() {}
+
+func (t *TryStatement) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *TryStatement) Pos() token.Position { return t.Token.Pos }
+
+func (t *TryStatement) End() token.Position {
+	switch {
+	case t.Finally != nil:
+		return t.Finally.End()
+	case t.Catch != nil:
+		return t.Catch.End()
+	case t.Block != nil:
+		return t.Block.End()
+	}
+	return t.Token.End()
+}
+
+func (t *TryStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("try ")
+	out.WriteString(t.Block.String())
+	if t.Catch != nil {
+		out.WriteString(" catch (")
+		out.WriteString(t.Param.String())
+		out.WriteString(") ")
+		out.WriteString(t.Catch.String())
+	}
+	if t.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(t.Finally.String())
+	}
+	return out.String()
+}
+
+// ThrowStatement throw Value;
+type ThrowStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (t *ThrowStatement) statementNode() {}
+
+func (t *ThrowStatement) TokenLiteral() string {
+	return t.Token.Literal
+}
+
+func (t *ThrowStatement) Pos() token.Position { return t.Token.Pos }
+
+func (t *ThrowStatement) End() token.Position {
+	if t.Value != nil {
+		return t.Value.End()
+	}
+	return t.Token.End()
+}
+
+func (t *ThrowStatement) String() string {
+	return "throw " + t.Value.String() + ";"
+}
@@ -25,11 +25,14 @@ var buildins = map[string]*object.Buildin{
 	"puts": {
 		Fn: buildinPuts,
 	},
+	"freeze": {
+		Fn: buildinFreeze,
+	},
 }
 
 func buildinLen(args ...object.Object) object.Object {
 	if len(args) != 1 {
-		return newError("wrong number of arguments. got=%d, want=1", len(args))
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 	}
 
 	switch arg := args[0].(type) {
@@ -38,17 +41,17 @@ func buildinLen(args ...object.Object) object.Object {
 	case *object.String:
 		return &object.Integer{Value: int64(len(arg.Value))}
 	default:
-		return newError("argument to `len` not supported, got %s", args[0].Type())
+		return newError(object.TYPE_ERROR, "argument to `len` not supported, got %s", args[0].Type())
 	}
 }
 
 func buildinFirst(args ...object.Object) object.Object {
 	if len(args) != 1 {
-		return newError("wrong number of arguments. got=%d, want=1", len(args))
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 	}
 
 	if args[0].Type() != object.ARRAY_OBJ {
-		return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
+		return newError(object.TYPE_ERROR, "argument to `first` must be ARRAY, got %s", args[0].Type())
 	}
 
 	arr := args[0].(*object.Array)
@@ -60,11 +63,11 @@ func buildinFirst(args ...object.Object) object.Object {
 
 func buildinLast(args ...object.Object) object.Object {
 	if len(args) != 1 {
-		return newError("wrong number of arguments. got=%d, want=1", len(args))
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 	}
 
 	if args[0].Type() != object.ARRAY_OBJ {
-		return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
+		return newError(object.TYPE_ERROR, "argument to `last` must be ARRAY, got %s", args[0].Type())
 	}
 
 	arr := args[0].(*object.Array)
@@ -77,11 +80,11 @@ func buildinLast(args ...object.Object) object.Object {
 
 func buildinRest(args ...object.Object) object.Object {
 	if len(args) != 1 {
-		return newError("wrong number of arguments. got=%d, want=1", len(args))
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 	}
 
 	if args[0].Type() != object.ARRAY_OBJ {
-		return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
+		return newError(object.TYPE_ERROR, "argument to `last` must be ARRAY, got %s", args[0].Type())
 	}
 
 	arr := args[0].(*object.Array)
@@ -96,11 +99,11 @@ func buildinRest(args ...object.Object) object.Object {
 
 func buildinPush(args ...object.Object) object.Object {
 	if len(args) != 2 {
-		return newError("wrong number of arguments. got=%d, want=2", len(args))
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
 	}
 
 	if args[0].Type() != object.ARRAY_OBJ {
-		return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
+		return newError(object.TYPE_ERROR, "argument to `last` must be ARRAY, got %s", args[0].Type())
 	}
 
 	arr := args[0].(*object.Array)
@@ -113,6 +116,22 @@ func buildinPush(args ...object.Object) object.Object {
 	return &object.Array{Elements: newElement}
 }
 
+// buildinFreeze 冻结数组或哈希，之后对它的索引赋值会产生错误。
+// 冻结是浅层的，其他类型的值本身不可变，原样返回
+func buildinFreeze(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Array:
+		arg.Frozen = true
+	case *object.Hash:
+		arg.Frozen = true
+	}
+	return args[0]
+}
+
 func buildinPuts(args ...object.Object) object.Object {
 
 	for _, arg := range args {
@@ -2,18 +2,58 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 
 	"github.com/fengshux/monkey/ast"
 	"github.com/fengshux/monkey/object"
+	"github.com/fengshux/monkey/token"
 )
 
 var (
 	NULL  = &object.Null{}
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// maxCallDepth 限制函数调用的嵌套深度，避免无限递归耗尽 Go 的栈导致进程退出
+const maxCallDepth = 10000
+
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	res := evalNode(node, env)
+	// 不产生值的语句块（例如空的函数体）作为表达式使用时，值为 null
+	if _, ok := node.(ast.Expression); ok && res == nil {
+		return NULL
+	}
+	// 错误对象记录产生错误的最内层节点的位置
+	if err, ok := res.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+	return res
+}
+
+// EvalProgram 定义并展开程序中的宏，然后在 env 中求值。
+// 这是解释器的最外层边界：求值中意外的 panic 会转换成 InternalError，宿主程序和 REPL 不会因此崩溃
+func EvalProgram(program *ast.Program, env, macroEnv *object.Environment) (res object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = newError(object.INTERNAL_ERROR, "%v", r)
+		}
+	}()
+
+	DefineMacros(program, macroEnv)
+	expanded, err := ExpandMacros(program, macroEnv)
+	if err != nil {
+		return err
+	}
+	return Eval(expanded, env)
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// 语句
 	case *ast.Program:
@@ -33,25 +73,56 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if err := evalLetBinding(node, val, env); err != nil {
+			return err
+		}
+	case *ast.FunctionStatement:
+		// 具名函数已经在 hoistFunctions 中绑定
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
+	case *ast.ThrowStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return newThrownError(val)
+	case *ast.BranchStatement:
+		if node.Token.Type == token.BREAK {
+			return BREAK
+		}
+		return CONTINUE
 
 	// 表达式
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return NULL
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.ImportExpression:
+		return evalImportExpression(node, env)
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+	case *ast.BadStatement, *ast.BadExpression:
+		return newError(object.SYNTAX_ERROR, "invalid syntax")
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.Identifer:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
-		params := node.Parameters
-		body := node.Body
-		return &object.Function{
-			Parameters: params,
-			Body:       body,
-			Env:        env,
-		}
+		return newFunction(node, env)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -59,6 +130,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -68,10 +142,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return right
 		}
 		return evalInfixExpression(node.Operator, left, right)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 	case *ast.CallExpression:
 		if node.Function.TokenLiteral() == "quote" {
+			if len(node.Arguments) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to quote. got=%d, want=1", len(node.Arguments))
+			}
 			return quote(node.Arguments[0], env)
 		}
 
@@ -83,7 +162,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		kwargs, err := evalKeywordArguments(node.Keywords, env)
+		if err != nil {
+			return err
+		}
+		return applyFunction(function, args, kwargs, env.Depth()+1, node.Pos())
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -107,8 +190,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	return nil
 }
 
-func newError(format string, args ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, args...)}
+func newError(kind string, format string, args ...interface{}) *object.Error {
+	return &object.Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
 }
 
 func isError(obj object.Object) bool {
@@ -133,6 +216,22 @@ func evalIfExpression(exp *ast.IfExpression, env *object.Environment) object.Obj
 
 }
 
+// evalLogicalExpression 对 && 和 || 进行短路求值，结果为决定表达式真假的那个操作数
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if node.Operator == "&&" && !isTruthy(left) {
+		return left
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return left
+	}
+	return Eval(node.Right, env)
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -147,6 +246,10 @@ func isTruthy(obj object.Object) bool {
 }
 
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	if err := hoistFunctions(program.Statements, env); err != nil {
+		return err
+	}
+
 	var res object.Object
 
 	for _, stmt := range program.Statements {
@@ -163,12 +266,17 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 }
 
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	if err := hoistFunctions(block.Statements, env); err != nil {
+		return err
+	}
+
 	var res object.Object
 	for _, stmt := range block.Statements {
 		res = Eval(stmt, env)
 
 		if res != nil {
-			if res.Type() == object.RETURN_VALUE_OBJ || res.Type() == object.ERROR_OBJ {
+			switch res.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return res
 			}
 		}
@@ -176,6 +284,203 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	return res
 }
 
+// hoistFunctions 在语句块执行之前绑定其中所有的具名函数声明，
+// 这样函数可以在声明之前调用，也可以互相递归
+func hoistFunctions(stmts []ast.Statement, env *object.Environment) object.Object {
+	for _, stmt := range stmts {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = export.Statement
+		}
+		decl, ok := stmt.(*ast.FunctionStatement)
+		if !ok {
+			continue
+		}
+		if env.IsLocalConst(decl.Name.Value) {
+			err := newError(object.CONST_ERROR, "cannot redeclare constant: %s", decl.Name.Value)
+			err.Pos = decl.Pos()
+			return err
+		}
+		fn := newFunction(decl.Function, env)
+		fn.Name = decl.Name.Value
+		env.Set(decl.Name.Value, fn)
+	}
+	return nil
+}
+
+func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return NULL
+		}
+		if done, res := loopControl(Eval(node.Body, env)); done {
+			return res
+		}
+	}
+}
+
+// evalForInStatement 遍历数组的元素、哈希的键或者字符串的字符，哈希的键和 keys() 的顺序相同。
+// 每次迭代都在新的内层环境中绑定循环变量并执行循环体
+func evalForInStatement(node *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var items []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		items = append(items, iterable.Elements...)
+	case *object.Hash:
+		for _, pair := range sortedPairs(iterable) {
+			items = append(items, pair.Key)
+		}
+	case *object.String:
+		for _, r := range iterable.Value {
+			items = append(items, &object.String{Value: string(r)})
+		}
+	default:
+		return newError(object.TYPE_ERROR, "cannot iterate over %s", iterable.Type())
+	}
+
+	for _, item := range items {
+		// 循环体中创建的闭包各自捕获当次的循环变量，循环结束后循环变量也不会留在外层
+		iterEnv := object.NewEnclosedEnvironment(env)
+		iterEnv.Set(node.Variable.Value, item)
+		if done, res := loopControl(Eval(node.Body, iterEnv)); done {
+			return res
+		}
+	}
+	return NULL
+}
+
+// loopControl 根据循环体的执行结果判断循环是否结束，结束时同时返回循环的值
+func loopControl(res object.Object) (bool, object.Object) {
+	switch res := res.(type) {
+	case *object.Break:
+		return true, NULL
+	case *object.ReturnValue, *object.Error:
+		return true, res
+	}
+	return false, nil
+}
+
+// evalTryStatement 执行 try 语句块，出错时把错误转换成哈希绑定到 catch 的参数上再执行 catch 语句块。
+// finally 语句块总是会执行，其中的 return、break、continue 和错误会覆盖之前的结果
+func evalTryStatement(node *ast.TryStatement, env *object.Environment) object.Object {
+	res := Eval(node.Block, env)
+
+	if err, ok := res.(*object.Error); ok && node.Catch != nil {
+		// 与 match 的分支一样，catch 的参数只在 catch 语句块中可见
+		catchEnv := object.NewEnclosedEnvironment(env)
+		catchEnv.Set(node.Param.Value, errorToHash(err))
+		res = Eval(node.Catch, catchEnv)
+		// catch 语句块中产生的新错误以正在处理的错误为原因
+		if next, ok := res.(*object.Error); ok && next.Cause == nil && !causedBy(err, next) {
+			next.Cause = err
+		}
+	}
+
+	if node.Finally != nil {
+		switch fin := Eval(node.Finally, env).(type) {
+		case *object.ReturnValue, *object.Error, *object.Break, *object.Continue:
+			return fin
+		}
+	}
+	return res
+}
+
+// causedBy 判断 target 是否是 err 本身或者 err 的原因链中的错误，再次抛出时得到的副本也算作同一个错误
+func causedBy(err, target *object.Error) bool {
+	for ; err != nil; err = err.Cause {
+		if err == target || sameError(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameError 判断两个错误是否来自同一次抛出
+func sameError(a, b *object.Error) bool {
+	return a.Message == b.Message && a.Kind == b.Kind && a.Pos == b.Pos && a.Value == b.Value
+}
+
+// newThrownError 把 throw 的值包装成错误。抛出带有 message 的哈希时沿用其中的 message、kind 和 cause，
+// 抛出 catch 得到的哈希时沿用原来错误的副本，之后添加的调用栈和原因不会改变之前 catch 得到的错误
+func newThrownError(val object.Object) *object.Error {
+	err := &object.Error{Kind: object.GENERIC_ERROR, Value: val}
+	switch val := val.(type) {
+	case *object.String:
+		err.Message = val.Value
+	case *object.Hash:
+		if val.Err != nil {
+			rethrown := *val.Err
+			rethrown.Trace = append([]object.Frame(nil), val.Err.Trace...)
+			return &rethrown
+		}
+		err.Message = val.Inspect()
+		if msg, ok := hashField(val, "message").(*object.String); ok {
+			err.Message = msg.Value
+			if kind, ok := hashField(val, "kind").(*object.String); ok {
+				err.Kind = kind.Value
+			}
+			if cause, ok := hashField(val, "cause").(*object.Hash); ok {
+				err.Cause = cause.Err
+			}
+			err.Value = hashField(val, "value")
+		}
+	default:
+		err.Message = val.Inspect()
+	}
+	return err
+}
+
+// errorToHash 把错误转换成 catch 得到的哈希，包含 message、kind、position、throw 抛出的 value
+// 以及同样转换成哈希的 cause
+func errorToHash(err *object.Error) *object.Hash {
+	kind := err.Kind
+	if kind == "" {
+		kind = object.GENERIC_ERROR
+	}
+	var value object.Object = NULL
+	if err.Value != nil {
+		value = err.Value
+	}
+	var cause object.Object = NULL
+	if err.Cause != nil {
+		cause = errorToHash(err.Cause)
+	}
+
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair), Err: err}
+	fields := []struct {
+		name  string
+		value object.Object
+	}{
+		{"message", &object.String{Value: err.Message}},
+		{"kind", &object.String{Value: kind}},
+		{"position", &object.String{Value: err.Pos.String()}},
+		{"value", value},
+		{"cause", cause},
+	}
+	for _, f := range fields {
+		key := &object.String{Value: f.name}
+		hash.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: f.value}
+	}
+	return hash
+}
+
+// hashField 返回哈希中字符串键 name 对应的值，不存在时返回 nil
+func hashField(hash *object.Hash, name string) object.Object {
+	key := &object.String{Value: name}
+	if pair, ok := hash.Pairs[key.HashKey()]; ok {
+		return pair.Value
+	}
+	return nil
+}
+
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
 		return TRUE
@@ -189,8 +494,10 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalBitNotOperatorExpression(right)
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -208,17 +515,39 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return normalizeBigInt(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return normalizeBigInt(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: -%s", right.Type())
+	}
+}
+
+func evalBitNotOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: ^right.Value}
+	case *object.BigInt:
+		return normalizeBigInt(new(big.Int).Not(right.Value))
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: ~%s", right.Type())
 	}
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// 整数和浮点数混合运算时，整数提升为浮点数
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpressoin(operator, left, right)
 	case operator == "==":
@@ -226,47 +555,277 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	case operator == "!=":
 		return nativeBoolToBooleanObject(left != right)
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
+	leftInt, ok := left.(*object.Integer)
+	if !ok {
+		return evalBigIntInfixExpression(operator, left, right)
+	}
+	rightInt, ok := right.(*object.Integer)
+	if !ok {
+		return evalBigIntInfixExpression(operator, left, right)
+	}
+	leftVal := leftInt.Value
+	rightVal := rightInt.Value
+
+	switch operator {
+	case "+", "-", "*":
+		res, ok := integerArithmetic(operator, leftVal, rightVal)
+		if !ok {
+			// 超出int64范围，改用BigInt计算
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: res}
+	case "/":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero: %d / %d", leftVal, rightVal)
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "modulo by zero: %d %% %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case ">>":
+		if rightVal < 0 {
+			return newError(object.ARITHMETIC_ERROR, "negative shift count: %d", rightVal)
+		}
+		return &object.Integer{Value: leftVal >> uint64(rightVal)}
+	case "<<", "**":
+		// 结果很容易超出int64范围，直接用BigInt计算
+		return evalBigIntInfixExpression(operator, left, right)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
 
+// integerArithmetic 计算 + - *，结果超出int64范围时ok为false
+func integerArithmetic(operator string, a, b int64) (res int64, ok bool) {
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		res = a + b
+		return res, (a^res)&(b^res) >= 0
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		res = a - b
+		return res, (a^b)&(a^res) >= 0
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		res = a * b
+		return res, res/b == a && !(a == math.MinInt64 && b == -1)
+	}
+	return 0, false
+}
+
+func evalBigIntInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toBigInt(left)
+	rightVal := toBigInt(right)
+
+	switch operator {
+	case "+":
+		return normalizeBigInt(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return normalizeBigInt(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return normalizeBigInt(new(big.Int).Mul(leftVal, rightVal))
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		if rightVal.Sign() == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero: %s / %s", leftVal, rightVal)
+		}
+		// Quo 和int64的除法一样向零取整
+		return normalizeBigInt(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError(object.ARITHMETIC_ERROR, "modulo by zero: %s %% %s", leftVal, rightVal)
+		}
+		// Rem 和int64的取模一样，结果的符号与被除数相同
+		return normalizeBigInt(new(big.Int).Rem(leftVal, rightVal))
+	case "&":
+		return normalizeBigInt(new(big.Int).And(leftVal, rightVal))
+	case "|":
+		return normalizeBigInt(new(big.Int).Or(leftVal, rightVal))
+	case "^":
+		return normalizeBigInt(new(big.Int).Xor(leftVal, rightVal))
+	case "<<", ">>":
+		return evalShift(operator, leftVal, rightVal)
+	case "**":
+		return evalIntegerPower(leftVal, rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// maxIntegerBits 限制移位和乘方结果的大小，避免一个表达式耗尽内存
+const maxIntegerBits = 1 << 24
+
+func evalShift(operator string, value, count *big.Int) object.Object {
+	if count.Sign() < 0 {
+		return newError(object.ARITHMETIC_ERROR, "negative shift count: %s", count)
+	}
+	if operator == ">>" {
+		// 右移超过数字的位数时结果只取决于符号，不需要移动更多位
+		n := uint(value.BitLen() + 1)
+		if count.IsInt64() && count.Int64() < int64(n) {
+			n = uint(count.Int64())
+		}
+		return normalizeBigInt(new(big.Int).Rsh(value, n))
+	}
+	if !count.IsInt64() || count.Int64() > maxIntegerBits {
+		return newError(object.ARITHMETIC_ERROR, "shift count too large: %s", count)
+	}
+	return normalizeBigInt(new(big.Int).Lsh(value, uint(count.Int64())))
+}
+
+// evalIntegerPower 计算整数乘方，指数为负数时结果为浮点数
+func evalIntegerPower(base, exp *big.Int) object.Object {
+	if exp.Sign() < 0 {
+		b, _ := new(big.Float).SetInt(base).Float64()
+		e, _ := new(big.Float).SetInt(exp).Float64()
+		return &object.Float{Value: math.Pow(b, e)}
+	}
+	// 底数为 0、1、-1 时结果不会变大
+	if base.CmpAbs(big.NewInt(1)) > 0 {
+		if !exp.IsInt64() || exp.Int64() > maxIntegerBits/int64(base.BitLen()) {
+			return newError(object.ARITHMETIC_ERROR, "integer too large: %s ** %s", base, exp)
+		}
+	}
+	return normalizeBigInt(new(big.Int).Exp(base, exp, nil))
+}
+
+// toBigInt 将Integer或BigInt对象转换为*big.Int
+func toBigInt(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	default:
+		return new(big.Int)
+	}
+}
+
+// normalizeBigInt 结果能放入int64时返回Integer，否则返回BigInt
+func normalizeBigInt(v *big.Int) object.Object {
+	if v.IsInt64() {
+		return &object.Integer{Value: v.Int64()}
+	}
+	return &object.BigInt{Value: v}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// toFloat 将整数或浮点数对象转换为float64
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
 func evalStringInfixExpressoin(operator string, left, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
 
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
 }
 
 func evalIdentifier(node *ast.Identifer, env *object.Environment) object.Object {
@@ -277,7 +836,7 @@ func evalIdentifier(node *ast.Identifer, env *object.Environment) object.Object
 	if buildin, ok := buildins[node.Value]; ok {
 		return buildin
 	}
-	return newError("identifier not found: " + node.Value)
+	return newError(object.NAME_ERROR, "identifier not found: %s", node.Value)
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
@@ -292,26 +851,152 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return res
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
+// evalInterpolatedString 将插值表达式的值以 Inspect() 的结果拼接到字符串中
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+	for i, part := range node.Parts {
+		out.WriteString(node.Strings[i])
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+		out.WriteString(val.Inspect())
+	}
+	out.WriteString(node.Strings[len(node.Strings)-1])
+	return &object.String{Value: out.String()}
+}
+
+func newFunction(fn *ast.FunctionLiteral, env *object.Environment) *object.Function {
+	return &object.Function{
+		Parameters: fn.Parameters,
+		Rest:       fn.Rest,
+		Body:       fn.Body,
+		Env:        env,
+	}
+}
+
+// evalKeywordArguments 求值命名实参，没有命名实参时返回nil
+func evalKeywordArguments(keywords []*ast.KeywordArgument, env *object.Environment) (map[string]object.Object, *object.Error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+	kwargs := make(map[string]object.Object, len(keywords))
+	for _, k := range keywords {
+		val := Eval(k.Value, env)
+		if err, ok := val.(*object.Error); ok {
+			return nil, err
+		}
+		kwargs[k.Name.Value] = val
+	}
+	return kwargs, nil
+}
+
+// extendFunctionEnv 把实参绑定到形参上：先绑定位置实参和命名实参，多余的位置实参放入剩余参数，
+// 最后按顺序为未绑定的形参求值默认值，默认值可以引用前面的参数
+func extendFunctionEnv(fn *object.Function, args []object.Object, kwargs map[string]object.Object, depth int) (*object.Environment, *object.Error) {
+	env := object.NewCallEnvironment(fn.Env, depth)
+
+	if len(args) > len(fn.Parameters) && fn.Rest == nil {
+		return nil, newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want at most %d", len(args), len(fn.Parameters))
+	}
+
+	bound := make([]bool, len(fn.Parameters))
+	for i := range fn.Parameters {
+		if i < len(args) {
+			if err := bindParameter(fn, i, args[i], env); err != nil {
+				return nil, err
+			}
+			bound[i] = true
+		}
+	}
+
+	for name, val := range kwargs {
+		i := parameterIndex(fn, name)
+		if i < 0 {
+			return nil, newError(object.ARGUMENT_ERROR, "unexpected keyword argument: %s", name)
+		}
+		if bound[i] {
+			return nil, newError(object.ARGUMENT_ERROR, "multiple values for argument: %s", name)
+		}
+		if err := bindParameter(fn, i, val, env); err != nil {
+			return nil, err
+		}
+		bound[i] = true
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
+	}
+
+	for i, p := range fn.Parameters {
+		if bound[i] {
+			continue
+		}
+		if p.Default == nil {
+			return nil, newError(object.ARGUMENT_ERROR, "missing argument: %s", p)
+		}
+		val := Eval(p.Default, env)
+		if err, ok := val.(*object.Error); ok {
+			return nil, err
+		}
+		if err := bindParameter(fn, i, val, env); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// bindParameter 把val绑定到第i个形参，解构的形参按照模式绑定
+func bindParameter(fn *object.Function, i int, val object.Object, env *object.Environment) *object.Error {
+	param := fn.Parameters[i]
+	if param.Pattern != nil {
+		return bindPattern(param.Pattern, val, func(name string, val object.Object) *object.Error {
+			env.Set(name, val)
+			return nil
+		})
+	}
+	env.Set(param.Name.Value, val)
+	return nil
+}
 
+func parameterIndex(fn *object.Function, name string) int {
 	for i, p := range fn.Parameters {
-		env.Set(p.Value, args[i])
+		if p.Name != nil && p.Name.Value == name {
+			return i
+		}
 	}
-	return env
+	return -1
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// applyFunction 调用函数，depth 是这次调用在调用栈中的深度，pos 是调用发生的位置。
+// 函数体中产生的错误向外传播时，会在错误的调用栈中记录这次调用
+func applyFunction(fn object.Object, args []object.Object, kwargs map[string]object.Object, depth int, pos token.Position) object.Object {
 
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendEnv := extendFunctionEnv(fn, args)
+		if depth > maxCallDepth {
+			return newError(object.RECURSION_ERROR, "maximum call depth exceeded: %d", maxCallDepth)
+		}
+		extendEnv, err := extendFunctionEnv(fn, args, kwargs, depth)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendEnv)
+		if err, ok := evaluated.(*object.Error); ok {
+			err.Trace = append(err.Trace, object.Frame{Function: fn.Name, Pos: pos})
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Buildin:
+		if len(kwargs) > 0 {
+			return newError(object.ARGUMENT_ERROR, "keyword arguments not supported by builtin functions")
+		}
 		return fn.Fn(args...)
 	default:
-		return newError("not a funciton: %s", fn.Type())
+		return newError(object.TYPE_ERROR, "not a funciton: %s", fn.Type())
 	}
 
 }
@@ -329,14 +1014,21 @@ func evalIndexExpression(left, index object.Object) object.Object {
 		return evalArrayIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.MODULE_OBJ && index.Type() == object.STRING_OBJ:
+		return evalModuleMember(left.(*object.Module), index.(*object.String).Value)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError(object.TYPE_ERROR, "index operator not supported: %s", left.Type())
 	}
 }
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		// BigInt 一定超出数组范围
+		return NULL
+	}
+	idx := integer.Value
 	max := int64(len(arrayObject.Elements) - 1)
 
 	if idx < 0 || idx > max {
@@ -345,6 +1037,253 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+func evalLetBinding(node *ast.LetStatement, val object.Object, env *object.Environment) *object.Error {
+	var target ast.Expression = node.Pattern
+	if node.Name != nil {
+		target = node.Name
+		// let f = fn() { } 绑定的函数以变量名作为函数名
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+				fn.Name = node.Name.Value
+			}
+		}
+	}
+
+	return bindPattern(target, val, func(name string, val object.Object) *object.Error {
+		if env.IsLocalConst(name) {
+			return newError(object.CONST_ERROR, "cannot redeclare constant: %s", name)
+		}
+		if node.Token.Type == token.CONST {
+			env.SetConst(name, val)
+		} else {
+			env.Set(name, val)
+		}
+		return nil
+	})
+}
+
+// bindPattern 按照模式解构val，对每个标识符调用bind。数组和哈希中缺少的元素绑定为null
+func bindPattern(pattern ast.Expression, val object.Object, bind func(string, object.Object) *object.Error) *object.Error {
+	switch pattern := pattern.(type) {
+	case *ast.Identifer:
+		return bind(pattern.Value, val)
+	case *ast.ArrayPattern:
+		arr, ok := val.(*object.Array)
+		if !ok {
+			return newError(object.TYPE_ERROR, "cannot destructure %s as ARRAY", val.Type())
+		}
+		for i, el := range pattern.Elements {
+			item := evalArrayIndexExpression(arr, &object.Integer{Value: int64(i)})
+			if err := bindPattern(el, item, bind); err != nil {
+				return err
+			}
+		}
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if len(arr.Elements) > len(pattern.Elements) {
+				rest = append(rest, arr.Elements[len(pattern.Elements):]...)
+			}
+			return bind(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+		return nil
+	case *ast.HashPattern:
+		if _, ok := val.(*object.Hash); !ok {
+			return newError(object.TYPE_ERROR, "cannot destructure %s as HASH", val.Type())
+		}
+		for i, key := range pattern.Keys {
+			item := evalHashIndexExpression(val, &object.String{Value: ast.PatternKey(key)})
+			if err := bindPattern(pattern.Values[i], item, bind); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return newError(object.TYPE_ERROR, "invalid binding target: %s", pattern.String())
+	}
+}
+
+// evalMatchExpression 依次尝试每个分支，模式匹配且 guard 为真时在新的环境中求值分支，
+// 模式中的绑定只在分支内可见。没有分支匹配时返回null
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return Eval(arm.Body, armEnv)
+	}
+	return NULL
+}
+
+// matchPattern 判断val是否匹配pattern，匹配过程中的绑定写入env
+func matchPattern(pattern ast.Expression, val object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifer:
+		if pattern.Value != "_" {
+			env.Set(pattern.Value, val)
+		}
+		return true, nil
+	case *ast.TypePattern:
+		if !knownTypes[object.ObjectType(pattern.Type.Value)] {
+			err := newError(object.NAME_ERROR, "unknown type in pattern: %s", pattern.Type.Value)
+			err.Pos = pattern.Type.Pos()
+			return false, err
+		}
+		if val.Type() != object.ObjectType(pattern.Type.Value) {
+			return false, nil
+		}
+		return matchPattern(pattern.Pattern, val, env)
+	case *ast.ArrayPattern:
+		arr, ok := val.(*object.Array)
+		if !ok || len(arr.Elements) < len(pattern.Elements) {
+			return false, nil
+		}
+		if pattern.Rest == nil && len(arr.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		for i, el := range pattern.Elements {
+			if matched, err := matchPattern(el, arr.Elements[i], env); !matched || err != nil {
+				return false, err
+			}
+		}
+		if pattern.Rest != nil {
+			rest := append([]object.Object{}, arr.Elements[len(pattern.Elements):]...)
+			env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+		return true, nil
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+		for i, key := range pattern.Keys {
+			pair, ok := hash.Pairs[(&object.String{Value: ast.PatternKey(key)}).HashKey()]
+			if !ok {
+				return false, nil
+			}
+			if matched, err := matchPattern(pattern.Values[i], pair.Value, env); !matched || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	default:
+		// 字面量模式
+		literal := Eval(pattern, env)
+		if err, ok := literal.(*object.Error); ok {
+			return false, err
+		}
+		return objectsEqual(literal, val), nil
+	}
+}
+
+// knownTypes 是类型测试模式中可以使用的类型名
+var knownTypes = map[object.ObjectType]bool{
+	object.INTEGER_OBJ:  true,
+	object.FLOAT_OBJ:    true,
+	object.BOOLEAN_OBJ:  true,
+	object.NULL_OBJ:     true,
+	object.STRING_OBJ:   true,
+	object.ARRAY_OBJ:    true,
+	object.HASH_OBJ:     true,
+	object.FUNCTION_OBJ: true,
+	object.BUILDIN_OBJ:  true,
+}
+
+// objectsEqual 判断两个值是否相等，数字之间按数值比较，不同类型的值不相等
+func objectsEqual(a, b object.Object) bool {
+	if a.Type() != b.Type() && !(isNumber(a) && isNumber(b)) {
+		return false
+	}
+	return evalInfixExpression("==", a, b) == TRUE
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifer:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if env.IsConst(target.Value) {
+			return newError(object.CONST_ERROR, "cannot assign to constant: %s", target.Value)
+		}
+		if !env.Assign(target.Value, val) {
+			return newError(object.NAME_ERROR, "cannot assign to undefined identifier: %s", target.Value)
+		}
+		return val
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return evalIndexAssignment(left, index, val)
+	case *ast.MemberExpression:
+		return evalMemberAssignment(target, node.Value, env)
+	default:
+		return newError(object.TYPE_ERROR, "cannot assign to %s", node.Target.String())
+	}
+}
+
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		if left.Frozen {
+			return newError(object.CONST_ERROR, "cannot modify frozen ARRAY")
+		}
+		if n, ok := index.(*object.BigInt); ok {
+			// BigInt 一定超出数组范围
+			return newError(object.INDEX_ERROR, "index out of range: %s", n.Inspect())
+		}
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			return newError(object.TYPE_ERROR, "array index must be INTEGER, got %s", index.Type())
+		}
+		if integer.Value < 0 || integer.Value >= int64(len(left.Elements)) {
+			return newError(object.INDEX_ERROR, "index out of range: %d", integer.Value)
+		}
+		left.Elements[integer.Value] = val
+	case *object.Hash:
+		if left.Frozen {
+			return newError(object.CONST_ERROR, "cannot modify frozen HASH")
+		}
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError(object.TYPE_ERROR, "unhashable as hash key: %s", index.Type())
+		}
+		left.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+	default:
+		return newError(object.TYPE_ERROR, "index assignment not supported: %s", left.Type())
+	}
+	return val
+}
+
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.HashKey]object.HashPair)
 
@@ -357,7 +1296,7 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return newError("unhashable as hash key: %s", key.Type())
+			return newError(object.TYPE_ERROR, "unhashable as hash key: %s", key.Type())
 		}
 
 		value := Eval(valueNode, env)
@@ -375,7 +1314,7 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("unhashable as hash key: %s", index.Type())
+		return newError(object.TYPE_ERROR, "unhashable as hash key: %s", index.Type())
 	}
 
 	pair, ok := hashObject.Pairs[key.HashKey()]
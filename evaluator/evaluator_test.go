@@ -346,6 +346,35 @@ func TestStringContatenation(t *testing.T) {
 	}
 }
 
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "monkey"; "Hello ${name}!"`, "Hello monkey!"},
+		{`"${1 + 2} items, ${len([1, 2])} left"`, "3 items, 2 left"},
+		{`"${true} ${[1, "a"]} ${1.5}"`, "true [1, a] 1.5"},
+		{`let f = fn(x) { "<${x}>" }; "${f("${1}")}"`, "<1>"},
+		{`"no \${interp}"`, "no ${interp}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("evaluated not object.String, got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("str.Value not %q, got=%q", tt.expected, str.Value)
+		}
+	}
+
+	errored := testEval(`"${1 + true}"`)
+	if _, ok := errored.(*object.Error); !ok {
+		t.Errorf("expected error for bad interpolation, got=%T (%+v)", errored, errored)
+	}
+}
+
 func TestBuildinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -540,3 +569,939 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + true;", "ERROR: 1:1: TypeError: type mismatch: INTEGER + BOOLEAN"},
+		{"let a = 1;\nlet b = a + foobar;", "ERROR: 2:13: NameError: identifier not found: foobar"},
+		{"let f = fn() {\n  -true\n};\nf();", "ERROR: 2:3: TypeError: unknown operator: -BOOLEAN\nTraceback (most recent call first):\n  at f (4:1)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Inspect() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q",
+				tt.expected, errObj.Inspect())
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `let 加 = fn(甲, 乙) { 甲 + 乙 }; 加("你好", "世界")`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("evaluated not object.String, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "你好世界" {
+		t.Fatalf("str.Value not %q, got=%q", "你好世界", str.Value)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"3.5 * 2", 7},
+		{"2 * 3.5", 7},
+		{"1 / 4.0", 0.25},
+		{"0.1 + 0.2 - 0.3 + 1e2", 0.1 + 0.2 - 0.3 + 1e2},
+		{"let half = fn(x) { x / 2.0 }; half(5)", 2.5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float, got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value, got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestMixedNumberComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"2.0 == 2", true},
+		{"2 != 2.5", true},
+		{"{2: 5}[2.0] == 5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIntegerOverflow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4611686018427387904 * 2", "9223372036854775808"},
+		{"let min = -9223372036854775807 - 1; min * -1", "9223372036854775808"},
+		{"let min = -9223372036854775807 - 1; min / -1", "9223372036854775808"},
+		{"let min = -9223372036854775807 - 1; -min", "9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBigIntObject(t, evaluated, tt.expected)
+	}
+
+	testIntegerObject(t, testEval("-9223372036854775807 - 1 + 1"), -9223372036854775807)
+	testIntegerObject(t, testEval("0xff * 0b10 + 1_000"), 1510)
+}
+
+func testBigIntObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.BigInt)
+	if !ok {
+		t.Errorf("object is not BigInt, got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Inspect() != expected {
+		t.Errorf("object has wrong value, got=%s, want=%s", result.Inspect(), expected)
+		return false
+	}
+	return true
+}
+
+func TestBigIntArithmetic(t *testing.T) {
+	input := `
+	let factorial = fn(n) { if (n < 2) { 1 } else { n * factorial(n - 1) } };
+	factorial(25)
+	`
+	testBigIntObject(t, testEval(input), "15511210043330985984000000")
+
+	// 结果回到int64范围内时变回Integer
+	testIntegerObject(t, testEval("9223372036854775807 * 10 / 10 - 7"), 9223372036854775800)
+
+	boolTests := []struct {
+		input    string
+		expected bool
+	}{
+		{"9223372036854775807 + 1 == 9223372036854775807 + 1", true},
+		{"9223372036854775807 + 1 > 9223372036854775807", true},
+		{"9223372036854775807 < 9223372036854775807 + 1", true},
+		{"(9223372036854775807 + 1) * 2 / 2 == 9223372036854775807 + 1", true},
+		{"(9223372036854775807 + 1) - 1 == 9223372036854775807", true},
+		{"9223372036854775807 + 1 != 9223372036854775807", true},
+		{"9223372036854775807 + 1 > 1.5", true},
+		{`let h = {9223372036854775807 + 1: 7}; h[9223372036854775807 + 1] == 7`, true},
+	}
+
+	for _, tt := range boolTests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestModuloPowerAndBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"7 % 3", 7 % 3},
+		{"-7 % 3", -7 % 3},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"2 ** 64", "18446744073709551616"},
+		{"2 ** -1", 0.5},
+		{"2.0 ** 0.5 * 2.0 ** 0.5", 2.0000000000000004},
+		{"7.5 % 2", 1.5},
+		{"12 & 10", 12 & 10},
+		{"12 | 10", 12 | 10},
+		{"12 ^ 10", 12 ^ 10},
+		{"~5", ^5},
+		{"1 << 10", 1024},
+		{"1 << 64", "18446744073709551616"},
+		{"(1 << 64) >> 60", 16},
+		{"-16 >> 2", -4},
+		{"-1 >> 100", -1},
+		{"(1 << 70) % 1000", 1180591620717411303424 % 1000},
+		{"((1 << 64) | 1) & 3", 1},
+		{"~(1 << 64)", "-18446744073709551617"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			testBigIntObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 % 0", "modulo by zero: 5 % 0"},
+		{"(1 << 64) % 0", "modulo by zero: 18446744073709551616 % 0"},
+		{"1 << -1", "negative shift count: -1"},
+		{"1 >> -1", "negative shift count: -1"},
+		{"1 << (1 << 64)", "shift count too large: 18446744073709551616"},
+		{"10 ** 100000000", "integer too large: 10 ** 100000000"},
+		{"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)",
+				tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		// 短路求值，右侧不会被求值
+		{"false && undefinedFunction()", false},
+		{"true || undefinedFunction()", true},
+		{"let x = 1; x > 0 && x < 10", true},
+		// 结果为决定真假的操作数
+		{"1 && 2", 2},
+		{"0 || 5", 0},
+		{"if (false) { 1 } || 7", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		}
+	}
+
+	evaluated := testEval("true && undefinedFunction()")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: undefinedFunction" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestLessOrGreaterEqual(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"2 >= 2", true},
+		{"1 >= 2", false},
+		{"1.5 <= 1.5", true},
+		{"2 >= 1.5", true},
+		{"(1 << 64) >= (1 << 64)", true},
+		{"(1 << 64) <= 1", false},
+		{`"abc" <= "abd"`, true},
+		{`"b" >= "abc"`, true},
+		{`"abc" < "abc"`, false},
+		{`"abc" == "abc"`, true},
+		{`"abc" != "abd"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 10000) { let i = i + 1; }; i", 10000},
+		{"while (false) { 1 }", nil},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { sum = sum + x; }; sum", 10},
+		{`let sum = 0; for (k in {1: "a", 2: "b", 3: "c"}) { sum = sum + k; }; sum`, 6},
+		{`let s = ""; for (k in {"c": 1, "a": 2, "d": 3, "b": 4}) { s = s + k; }; s`, "abcd"},
+		{`let n = 0; for (c in "héllo") { n = n + 1; }; n`, 5},
+		{`let s = ""; for (c in "abc") { s = c + s; }; s`, "cba"},
+		{"let x = 1; for (x in [7, 8]) {}; x", 1},
+		{"for (x in [7, 8]) {}; x", "identifier not found: x"},
+		{"let fs = []; for (x in [1, 2, 3]) { fs = push(fs, fn() { x }) }; fs[0]() + fs[1]() * 10 + fs[2]() * 100", 321},
+		{"let sum = 0; for (x in [1, 2]) { let y = x; sum = sum + y }; sum", 3},
+		{"let i = 0; while (true) { let i = i + 1; if (i == 5) { break; } }; i", 5},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x % 2 == 0) { continue; } sum = sum + x; }; sum", 4},
+		{"let sum = 0; for (x in [1, 2]) { for (y in [10, 20]) { if (y == 20) { break; } sum = sum + x * y; } }; sum", 30},
+		{"let f = fn(arr) { for (x in arr) { if (x > 2) { return x; } } return 0; }; f([1, 5, 3])", 5},
+		{"let f = fn() { while (true) { return 7; } }; f()", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if err, ok := evaluated.(*object.Error); ok {
+				if err.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Message)
+				}
+				continue
+			}
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("str.Value wrong. expected=%q, got=%q", expected, str.Value)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+
+	evaluated := testEval("for (x in 5) { x }")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "cannot iterate over INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = 1; a = 2; a", 2},
+		{"let a = 1; let b = 1; a = b = 3; a + b", 6},
+		{"let a = 1; (a = 5) + 1", 6},
+		{"let counter = fn() { let n = 0; fn() { n = n + 1 } }; let c = counter(); c(); c(); c()", 3},
+		{"let x = 1; let f = fn() { let x = 10; x = 20; x }; f() + x", 21},
+		{"let i = 0; for (x in [1, 2, 3]) { i = i + x }; i", 6},
+		{"let arr = [1, 2, 3]; arr[0] = 10; arr[0] + arr[2]", 13},
+		{"let arr = [[1], [2]]; arr[1][0] = 5; arr[1][0]", 5},
+		{`let h = {"a": 1}; h["a"] = 2; h["b"] = 3; h["a"] + h["b"]`, 5},
+		{"let h = {}; h[true] = 1; h[true]", 1},
+		{"let a = [1]; let b = a; b[0] = 9; a[0]", 9},
+		{"y = 1", "cannot assign to undefined identifier: y"},
+		{"let arr = [1]; arr[1] = 2", "index out of range: 1"},
+		{"let arr = [1]; arr[9223372036854775807 + 1] = 2", "index out of range: 9223372036854775808"},
+		{`let arr = [1]; arr["0"] = 2`, "array index must be INTEGER, got STRING"},
+		{"let h = {}; h[fn(x) { x }] = 1", "unhashable as hash key: FUNCTION"},
+		{"let s = \"ab\"; s[0] = \"c\"", "index assignment not supported: STRING"},
+		{"let a = 1; a = b", "identifier not found: b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestConstAndFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const a = 5; a * 2", 10},
+		{"const a = 5; let f = fn() { a + 1 }; f()", 6},
+		{"const arr = [1, 2]; arr[0] = 3; arr[0]", 3},
+		{"let arr = freeze([1, 2]); let b = push(arr, 3); b[2] = 4; b[2]", 4},
+		{"freeze(5) + 1", 6},
+		{"const a = 5; a = 6", "cannot assign to constant: a"},
+		{"const a = 5; let f = fn() { a = 6 }; f()", "cannot assign to constant: a"},
+		{"const a = 5; let a = 6", "cannot redeclare constant: a"},
+		{"const a = 5; const a = 6", "cannot redeclare constant: a"},
+		{"const x = 0; let r = 0; for (x in [5]) { r = x }; r + x", 5},
+		{"const x = 1; let f = fn() { let x = 2; x }; f() + x", 3},
+		{"const x = 1; let f = fn(x) { x }; f(5)", 5},
+		{"const x = 1; match (7) { x => x }", 7},
+		{"const x = 1; try { throw 4 } catch (x) { x[\"value\"] }", 4},
+		{"const x = 1; let f = fn() { x = 2 }; f()", "cannot assign to constant: x"},
+		{"const x = 1; let f = fn() { let x = 2; x = 3; x }; f() + x", 4},
+		{"const x = 1; if (true) { let x = 2 }", "cannot redeclare constant: x"},
+		{"let arr = freeze([1, 2]); arr[0] = 3", "cannot modify frozen ARRAY"},
+		{`const config = freeze({"debug": false}); config["debug"] = true`, "cannot modify frozen HASH"},
+		{"freeze()", "wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	evaluated := testEval("const limit = 10;\nlimit = 11;")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Pos.String() != "2:1" {
+		t.Errorf("wrong error position. got=%q", errObj.Pos)
+	}
+}
+
+func TestFunctionStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fn add(a, b) { a + b }; add(1, 2)", 3},
+		{"add(2, 3); fn add(a, b) { a + b }", nil},
+		{"let r = add(2, 3); fn add(a, b) { a + b }; r", 5},
+		{`
+		fn isEven(n) { if (n == 0) { true } else { isOdd(n - 1) } }
+		fn isOdd(n) { if (n == 0) { false } else { isEven(n - 1) } }
+		if (isEven(10)) { 1 } else { 0 }
+		`, 1},
+		{`
+		fn outer(x) {
+			return helper(x) * 2;
+			fn helper(y) { y + 1 }
+		}
+		outer(4)
+		`, 10},
+		{"fn fact(n) { if (n <= 1) { return 1; } n * fact(n - 1) } fact(5)", 120},
+		{"let f = fn(x) { x * 3 }; f(2)", 6},
+		{"const f = 1; let g = fn() { fn f() { 2 } f() }; g()", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			if evaluated != nil {
+				t.Errorf("expected nil for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn(x, y = 2) { x * y }; f(5)", 10},
+		{"let f = fn(x, y = 2) { x * y }; f(5, 3)", 15},
+		{"let f = fn(x, y = x + 1) { x * y }; f(3)", 12},
+		{"let f = fn(x = 1, y = 2) { x - y }; f(y: 10)", -9},
+		{"let f = fn(x, y) { x - y }; f(y: 1, x: 5)", 4},
+		{"let f = fn(first, ...rest) { len(rest) }; f(1, 2, 3)", 2},
+		{"let f = fn(first, ...rest) { len(rest) }; f(1)", 0},
+		{"let f = fn(...all) { all[1] }; f(7, 8, 9)", 8},
+		{"let f = fn(a, b = 10, ...rest) { a + b + len(rest) }; f(1, b: 2)", 3},
+		{"fn g(a, b = a * 2) { a + b } g(4)", 12},
+		{"let f = fn(x) { x }; f()", "missing argument: x"},
+		{"let f = fn(x, y) { x }; f(1)", "missing argument: y"},
+		{"let f = fn(x) { x }; f(1, 2)", "wrong number of arguments. got=2, want at most 1"},
+		{"let f = fn(x) { x }; f(z: 1)", "unexpected keyword argument: z"},
+		{"let f = fn(x) { x }; f(1, x: 2)", "multiple values for argument: x"},
+		{"let f = fn(x, y = z) { x }; f(1)", "identifier not found: z"},
+		{"len(x: [1])", "keyword arguments not supported by builtin functions"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	evaluated := testEval("let f = fn(first, ...rest) { rest }; f(1, 2, 3)")
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(arr.Elements) != 2 {
+		t.Fatalf("wrong num of elements. got=%d", len(arr.Elements))
+	}
+	testIntegerObject(t, arr.Elements[0], 2)
+	testIntegerObject(t, arr.Elements[1], 3)
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a + b", 3},
+		{"let [a, b, ...tail] = [1, 2, 3, 4]; len(tail) + tail[0]", 5},
+		{"let [a, ...tail] = [1]; len(tail)", 0},
+		{"let [a, b, c] = [1, 2]; c", nil},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", 6},
+		{`let {name, age} = {"name": "monkey", "age": 3}; age`, 3},
+		{`let {age: years} = {"age": 3}; years`, 3},
+		{`let {"first-name": first} = {"first-name": 7}; first`, 7},
+		{`let {missing} = {"x": 1}; missing`, nil},
+		{`let {pos: [x, y]} = {"pos": [3, 4]}; x * y`, 12},
+		{`let [{v}] = [{"v": 5}]; v`, 5},
+		{"let f = fn([a, b]) { a * b }; f([3, 4])", 12},
+		{`let f = fn({x, y} = {"x": 1, "y": 2}) { x + y }; f()`, 3},
+		{`fn g(n, {scale}) { n * scale } g(2, {"scale": 5})`, 10},
+		{"const [a, b] = [1, 2]; a = 3", "cannot assign to constant: a"},
+		{"let [a, b] = 5", "cannot destructure INTEGER as ARRAY"},
+		{"let {a} = [1]", "cannot destructure ARRAY as HASH"},
+		{"let f = fn([a]) { a }; f({})", "cannot destructure HASH as ARRAY"},
+		{"let f = fn([a]) { a }; f()", "missing argument: [a]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"match (2) { 1 => 10, 2 => 20, _ => 0 }", 20},
+		{"match (5) { 1 => 10, _ => 0 }", 0},
+		{"match (2.0) { 2 => 1, _ => 0 }", 1},
+		{"match (-3) { -3 => 1, _ => 0 }", 1},
+		{`match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{"match (true) { false => 0, true => 1 }", 1},
+		{"match (null) { null => 1, _ => 0 }", 1},
+		{"match (1) { \"1\" => 1, _ => 0 }", 0},
+		{"match (7) { n => n * 2 }", 14},
+		{"match ([1, 2]) { [a] => a, [a, b] => a + b, _ => 0 }", 3},
+		{"match ([1, 2, 3]) { [first, ...rest] => first + len(rest) }", 3},
+		{"match ([1, [2, 3]]) { [1, [x, 3]] => x, _ => 0 }", 2},
+		{`match ({"type": "circle", "r": 2}) { {type: "square", side} => side, {type: "circle", r} => r * 10 }`, 20},
+		{`match ({"a": 1}) { {b} => 1, _ => 2 }`, 2},
+		{`match ("s") { n: INTEGER => 1, s: STRING => 2, _ => 3 }`, 2},
+		{`match ([1]) { _: HASH => 1, _: ARRAY => 2 }`, 2},
+		{"match (5) { n if n > 10 => 1, n if n > 3 => 2, _ => 3 }", 2},
+		{"match (5) { n: INTEGER if n % 2 == 0 => 0, n: INTEGER => 1 }", 1},
+		{`match ({"p": [1, 2]}) { {p: [x, y]: ARRAY} => x + y }`, 3},
+		{"let n = 1; match (5) { n => n }; n", 1},
+		{"match (5) { 1 => 1 }", nil},
+		{"match (1) { _: NUMBER => 1 }", "unknown type in pattern: NUMBER"},
+		{"match (1) { n if n + true => 1 }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestTryCatchThrow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { throw "boom" } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom" } catch (e) { e["kind"] }`, "Error"},
+		{`try { throw 42 } catch (e) { e["value"] }`, 42},
+		{`try { throw {"message": "bad", "kind": "ValueError"} } catch (e) { e["kind"] + ": " + e["message"] }`, "ValueError: bad"},
+		{"try {\n  1 + true\n} catch (e) { e[\"message\"] + \" at \" + e[\"position\"] }", "type mismatch: INTEGER + BOOLEAN at 2:3"},
+		{`try { len(1) } catch (e) { e["message"] }`, "argument to `len` not supported, got INTEGER"},
+		{`let f = fn() { unknown }; try { f() } catch (e) { e["message"] }`, "identifier not found: unknown"},
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`let x = 0; try { x = 1 } finally { x = x + 10 }; x`, 11},
+		{`let x = 0; try { throw 1 } catch (e) { x = 2 } finally { x = x * 10 }; x`, 20},
+		{`let f = fn() { try { return 1 } finally { 2 } }; f()`, 1},
+		{`let f = fn() { try { return 1 } finally { return 2 } }; f()`, 2},
+		{`let f = fn() { try { throw "a" } catch (e) { return e["message"] } }; f()`, "a"},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e["message"] }`, "inner"},
+		{`try { try { throw "inner" } finally { 1 } } catch (e) { e["message"] }`, "inner"},
+		{`let n = 0; for (i in [1, 2, 3]) { try { if (i == 2) { break } } finally { n = n + 1 } }; n`, 2},
+		{`try { 1 } finally { throw "late" }`, "late"},
+		{`try { unknown } finally { 1 }`, "identifier not found: unknown"},
+		{`const e = 1; try { throw 1 } catch (e) { 2 }`, 2},
+		{`let e = 1; try { throw 2 } catch (e) { 0 }; e`, 1},
+		{`let saved = 0;
+		try { throw "first" } catch (e) { saved = e };
+		let r1 = 0;
+		try { try { throw "a" } catch (x) { throw saved } } catch (r) { r1 = r };
+		let r2 = 0;
+		try { throw saved } catch (r) { r2 = r };
+		r1["cause"]["message"] + (if (r2["cause"] == null) { "" } else { r2["cause"]["message"] })`, "a"},
+		{`try { throw 2 } catch (e) { 0 }; e`, "identifier not found: e"},
+		{`let x = 0; try { throw 5 } catch (e) { x = e["value"] }; x`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong string for %q. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  string
+	}{
+		{"1 + true", object.TYPE_ERROR},
+		{"-\"a\"", object.TYPE_ERROR},
+		{"foobar", object.NAME_ERROR},
+		{"x = 1", object.NAME_ERROR},
+		{"len(1, 2)", object.ARGUMENT_ERROR},
+		{"fn(x) { x }()", object.ARGUMENT_ERROR},
+		{"let a = [1]; a[5] = 2", object.INDEX_ERROR},
+		{"const c = 1; c = 2", object.CONST_ERROR},
+		{"5 % 0", object.ARITHMETIC_ERROR},
+		{`throw "x"`, object.GENERIC_ERROR},
+		{`throw {"message": "x", "kind": "ValueError"}`, "ValueError"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Kind != tt.kind {
+			t.Errorf("wrong kind for %q. expected=%q, got=%q", tt.input, tt.kind, errObj.Kind)
+		}
+	}
+}
+
+func TestErrorTraceAndCause(t *testing.T) {
+	input := `fn inner(x) {
+  x + true
+}
+let outer = fn(x) {
+  inner(x)
+};
+outer(1);`
+
+	errObj, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+	expectedTrace := []string{"inner 5:3", "outer 7:1"}
+	if len(errObj.Trace) != len(expectedTrace) {
+		t.Fatalf("wrong trace length. expected=%d, got=%d", len(expectedTrace), len(errObj.Trace))
+	}
+	for i, frame := range errObj.Trace {
+		if got := frame.Function + " " + frame.Pos.String(); got != expectedTrace[i] {
+			t.Errorf("wrong frame %d. expected=%q, got=%q", i, expectedTrace[i], got)
+		}
+	}
+
+	input = `let parse = fn(s) {
+  try {
+    unknown
+  } catch (e) {
+    throw "cannot parse " + s
+  }
+};
+parse("x");`
+
+	errObj, ok = testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+	expected := "ERROR: 5:5: Error: cannot parse x\n" +
+		"Traceback (most recent call first):\n" +
+		"  at parse (8:1)\n" +
+		"Caused by: ERROR: 3:5: NameError: identifier not found: unknown"
+	if errObj.Inspect() != expected {
+		t.Errorf("wrong inspect. expected=%q, got=%q", expected, errObj.Inspect())
+	}
+
+	causeTests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { try { foo } catch (e) { throw "wrapped" } } catch (e) { e["cause"]["kind"] }`, "NameError"},
+		{`try { try { foo } catch (e) { throw {"message": "m", "cause": e} } } catch (e) { e["cause"]["message"] }`, "identifier not found: foo"},
+		{`let e1 = null; try { foo } catch (e) { e1 = e }; try { throw e1 } catch (e) { e["position"] }`, "1:22"},
+		{`try { try { foo } catch (e) { throw e } } catch (e) { if (e["cause"] == null) { "none" } else { "some" } }`, "none"},
+	}
+
+	for _, tt := range causeTests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+}
+
+func TestRuntimePanicsBecomeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"10 / 0", "ERROR: 1:1: ArithmeticError: division by zero: 10 / 0"},
+		{"(9223372036854775807 + 1) / 0", "ERROR: 1:2: ArithmeticError: division by zero: 9223372036854775808 / 0"},
+		{"fn(x) { x }()", "ERROR: 1:1: ArgumentError: missing argument: x"},
+		{"quote()", "ERROR: 1:1: ArgumentError: wrong number of arguments to quote. got=0, want=1"},
+		{"quote(unquote(fn() { 1 }))", "ERROR: 1:7: TypeError: cannot unquote FUNCTION"},
+		{"quote(unquote(foo))", "ERROR: 1:15: NameError: identifier not found: foo"},
+		{"let f = fn() { }; f() + 1", "ERROR: 1:19: TypeError: type mismatch: NULL + INTEGER"},
+		{"fn f(n) { f(n + 1) }; f(0)",
+			"ERROR: 1:11: RecursionError: maximum call depth exceeded: 10000\n" +
+				"Traceback (most recent call first):\n" +
+				"  at f (1:11)\n" +
+				"  [previous frame repeated 9998 more times]\n" +
+				"  at f (1:23)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Inspect() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.Inspect())
+		}
+	}
+}
+
+func TestEvalProgramRecoversPanics(t *testing.T) {
+	buildins["explode"] = &object.Buildin{Fn: func(args ...object.Object) object.Object {
+		panic("boom")
+	}}
+	defer delete(buildins, "explode")
+
+	program := testParseProgram("let x = 1;\nexplode()")
+	res := EvalProgram(program, object.NewEnvironment(), object.NewEnvironment())
+	errObj, ok := res.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", res, res)
+	}
+	if errObj.Kind != object.INTERNAL_ERROR || errObj.Message != "boom" {
+		t.Errorf("wrong error. got=%s", errObj.Inspect())
+	}
+
+	program = testParseProgram("let m = macro() { 1 };\nm()")
+	res = EvalProgram(program, object.NewEnvironment(), object.NewEnvironment())
+	if errObj, ok := res.(*object.Error); !ok || errObj.Kind != object.TYPE_ERROR {
+		t.Errorf("expected macro expansion error. got=%T(%+v)", res, res)
+	}
+}
+
+func TestMemberAccessAndMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {"name": "monkey", "age": 3}; h.name`, "monkey"},
+		{`let h = {"p": {"x": 1}}; h.p.x`, 1},
+		{`let h = {}; h.missing`, nil},
+		{`let h = {"f": fn(x) { x * 2 }}; h.f(4)`, 8},
+		{`let h = {}; h.name = "x"; h.name = h.name + "y"; h["name"]`, "xy"},
+		{`let h = freeze({}); h.a = 1`, "cannot modify frozen HASH"},
+		{`[1, 2, 3].len()`, 3},
+		{`[1, 2, 3].first() + [1, 2, 3].last()`, 4},
+		{`let a = [1]; let b = a.push(2); a.len() * 10 + b.len()`, 12},
+		{`[1, 2, 3].rest().first()`, 2},
+		{`[1, "a", true].join("-")`, "1-a-true"},
+		{`[1, "a"].contains("a")`, true},
+		{`[1, 2].contains(3)`, false},
+		{`" Hello ".trim().upper()`, "HELLO"},
+		{`"ABC".lower()`, "abc"},
+		{`"a,b,c".split(",").len()`, 3},
+		{`"monkey".contains("key")`, true},
+		{`"abc".len()`, 3},
+		{`let h = {"b": 2, "a": 1}; h.keys().join(",")`, "a,b"},
+		{`let h = {"b": 2, "a": 1}; h.values()[1]`, 2},
+		{`{"a": 1}.has("a")`, true},
+		{`{"a": 1}.len()`, 1},
+		{`let h = {"len": 5}; h.len`, 5},
+		{`let f = "abc".upper; f()`, "ABC"},
+		{`[1].len(2)`, "wrong number of arguments. got=1, want=0"},
+		{`[1].push()`, "wrong number of arguments. got=0, want=1"},
+		{`"a".split(1)`, "argument to `split` must be STRING, got INTEGER"},
+		{`[1].nope()`, "ARRAY has no member: nope"},
+		{`5.abs()`, "INTEGER has no member: abs"},
+		{`let a = [1]; a.x = 2`, "cannot assign to member of ARRAY"},
+		{`x.y`, "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong string for %q. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	RegisterMethod(object.INTEGER_OBJ, "double", func(receiver object.Object, args ...object.Object) object.Object {
+		return &object.Integer{Value: receiver.(*object.Integer).Value * 2}
+	})
+	defer delete(methods, object.INTEGER_OBJ)
+
+	testIntegerObject(t, testEval("let n = 21; n.double()"), 42)
+}
@@ -0,0 +1,158 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fengshux/monkey/ast"
+	"github.com/fengshux/monkey/lexer"
+	"github.com/fengshux/monkey/object"
+	"github.com/fengshux/monkey/parser"
+	"github.com/fengshux/monkey/token"
+)
+
+// ModuleExt 是模块文件的扩展名，import 的路径没有扩展名时自动补上
+const ModuleExt = ".mk"
+
+// FileImporter 从文件系统加载模块，同一个文件只加载一次
+type FileImporter struct {
+	// SearchPath 是查找模块的目录列表。以 ./ 或 ../ 开头的路径相对于 import 所在的文件查找，
+	// 绝对路径直接使用，其他路径依次在这些目录中查找
+	SearchPath []string
+
+	modules map[string]*object.Module // 已经加载的模块，键为文件的绝对路径
+	loading []string                  // 正在加载的模块，用于检测循环导入
+}
+
+func NewFileImporter(searchPath ...string) *FileImporter {
+	return &FileImporter{
+		SearchPath: searchPath,
+		modules:    make(map[string]*object.Module),
+	}
+}
+
+// Import 加载 path 所指的模块。模块在自己的环境中求值，求值失败时返回以模块中的错误为原因的 ImportError
+func (imp *FileImporter) Import(path string, pos token.Position) object.Object {
+	filename, ok := imp.resolve(path, pos.Filename)
+	if !ok {
+		return newError(object.IMPORT_ERROR, "module not found: %s", path)
+	}
+	if module, ok := imp.modules[filename]; ok {
+		return module
+	}
+	for i, loading := range imp.loading {
+		if loading == filename {
+			cycle := append(imp.loading[i:], filename)
+			names := make([]string, len(cycle))
+			for j, f := range cycle {
+				names[j] = filepath.Base(f)
+			}
+			return newError(object.IMPORT_ERROR, "import cycle: %s", strings.Join(names, " -> "))
+		}
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return newError(object.IMPORT_ERROR, "cannot open module %s: %s", path, err)
+	}
+
+	p := parser.New(lexer.NewFile(filename, string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError(object.IMPORT_ERROR, "cannot parse module %s: %s", path, p.Errors().Error())
+	}
+
+	env := object.NewEnvironment()
+	env.SetImporter(imp)
+
+	imp.loading = append(imp.loading, filename)
+	res := EvalProgram(program, env, object.NewEnvironment())
+	imp.loading = imp.loading[:len(imp.loading)-1]
+
+	if cause, ok := res.(*object.Error); ok {
+		err := newError(object.IMPORT_ERROR, "cannot load module %s", path)
+		err.Cause = cause
+		return err
+	}
+
+	module := &object.Module{
+		Name:    strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)),
+		Path:    filename,
+		Env:     env,
+		Exports: exportedNames(program),
+	}
+	imp.modules[filename] = module
+	return module
+}
+
+// resolve 返回模块文件的绝对路径，from 是 import 所在的文件
+func (imp *FileImporter) resolve(path, from string) (string, bool) {
+	var candidates []string
+	switch {
+	case filepath.IsAbs(path):
+		candidates = []string{path}
+	case strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../"):
+		dir := "."
+		if from != "" {
+			dir = filepath.Dir(from)
+		}
+		candidates = []string{filepath.Join(dir, path)}
+	default:
+		for _, dir := range imp.SearchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, c := range candidates {
+		if filepath.Ext(c) == "" {
+			c += ModuleExt
+		}
+		if info, err := os.Stat(c); err == nil && info.Mode().IsRegular() {
+			if abs, err := filepath.Abs(c); err == nil {
+				return abs, true
+			}
+		}
+	}
+	return "", false
+}
+
+func evalImportExpression(node *ast.ImportExpression, env *object.Environment) object.Object {
+	importer := env.Importer()
+	if importer == nil {
+		return newError(object.IMPORT_ERROR, "import is not available: no importer configured")
+	}
+	return importer.Import(node.Path.Value, node.Pos())
+}
+
+// exportedNames 收集程序顶层 export 语句导出的所有名字
+func exportedNames(program *ast.Program) map[string]bool {
+	names := make(map[string]bool)
+	for _, stmt := range program.Statements {
+		export, ok := stmt.(*ast.ExportStatement)
+		if !ok {
+			continue
+		}
+		switch decl := export.Statement.(type) {
+		case *ast.FunctionStatement:
+			names[decl.Name.Value] = true
+		case *ast.LetStatement:
+			if decl.Name != nil {
+				names[decl.Name.Value] = true
+			} else {
+				for _, name := range ast.PatternNames(decl.Pattern) {
+					names[name.Value] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+func evalModuleMember(module *object.Module, name string) object.Object {
+	val, ok := module.Member(name)
+	if !ok {
+		return newError(object.NAME_ERROR, "module %s has no exported member: %s", module.Name, name)
+	}
+	return val
+}
@@ -0,0 +1,144 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fengshux/monkey/lexer"
+	"github.com/fengshux/monkey/object"
+	"github.com/fengshux/monkey/parser"
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// testEvalFile 以 dir 中 main.mk 的身份执行 input，dir 同时是模块的查找目录
+func testEvalFile(dir string, input string, importer *FileImporter) object.Object {
+	l := lexer.NewFile(filepath.Join(dir, "main.mk"), input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	env.SetImporter(importer)
+	return EvalProgram(program, env, object.NewEnvironment())
+}
+
+func TestImport(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib/math.mk": `
+			export fn square(x) { x * x }
+			export let pi = 3;
+			export const [one, two] = [1, 2];
+			let hidden = 42;
+			let count = 0;
+			export let loads = 0;
+			export fn inc() { count = count + 1; count }
+			`,
+		"lib/util.mk": `
+			let m = import "./math";
+			export fn cube(x) { m["square"](x) * x }
+			`,
+		"counter.mk": `
+			export let n = 0;
+			export fn bump() { n = n + 1 }
+			`,
+	})
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let m = import "lib/math"; m["square"](4)`, 16},
+		{`let m = import "lib/math"; m.square(m.pi)`, 9},
+		{`(import "lib/util").cube(2)`, 8},
+		{`let m = import "./lib/math.mk"; m["pi"] + m["one"] + m["two"]`, 6},
+		{`(import "lib/util")["cube"](3)`, 27},
+		{`let a = import "lib/math"; let b = import "./lib/math"; a == b`, true},
+		{`let a = import "lib/math"; a["inc"](); (import "lib/math")["inc"]()`, 2},
+		{`let c = import "counter"; c["bump"](); c["bump"](); c["n"]`, 2},
+		{`let m = import "lib/math"; m["hidden"]`, "module math has no exported member: hidden"},
+		{`let m = import "lib/math"; m["count"]`, "module math has no exported member: count"},
+		{`let m = import "lib/math"; m.hidden`, "module math has no exported member: hidden"},
+		{`let m = import "lib/math"; m.pi = 4`, "cannot assign to module member: pi"},
+		{`import "lib/missing"`, "module not found: lib/missing"},
+		{`import "./math"`, "module not found: ./math"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalFile(dir, tt.input, NewFileImporter(dir))
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a.mk":      `export let x = (import "./b")["y"];`,
+		"b.mk":      `export let y = (import "./a")["x"];`,
+		"broken.mk": "let y = 1 +;",
+		"fails.mk":  "let z = 1;\nz + true;",
+		"once.mk":   "export let n = 1;",
+	})
+
+	evaluated := testEvalFile(dir, `import "a"`, NewFileImporter(dir))
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	for errObj.Cause != nil {
+		errObj = errObj.Cause
+	}
+	if errObj.Kind != object.IMPORT_ERROR || errObj.Message != "import cycle: a.mk -> b.mk -> a.mk" {
+		t.Errorf("wrong cycle error. got=%s", errObj.Inspect())
+	}
+
+	evaluated = testEvalFile(dir, `import "broken"`, NewFileImporter(dir))
+	errObj, ok = evaluated.(*object.Error)
+	if !ok || !strings.HasPrefix(errObj.Message, "cannot parse module broken: ") {
+		t.Errorf("wrong parse error. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	evaluated = testEvalFile(dir, `import "fails"`, NewFileImporter(dir))
+	errObj, ok = evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "cannot load module fails" || errObj.Pos.Line != 1 || errObj.Cause == nil {
+		t.Fatalf("wrong load error. got=%s", errObj.Inspect())
+	}
+	cause := errObj.Cause
+	if cause.Kind != object.TYPE_ERROR || cause.Pos.Filename != filepath.Join(dir, "fails.mk") || cause.Pos.Line != 2 {
+		t.Errorf("wrong cause. got=%s", cause.Inspect())
+	}
+
+	evaluated = testEval(`import "once"`)
+	errObj, ok = evaluated.(*object.Error)
+	if !ok || errObj.Message != "import is not available: no importer configured" {
+		t.Errorf("expected missing importer error. got=%T(%+v)", evaluated, evaluated)
+	}
+}
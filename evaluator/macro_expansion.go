@@ -25,7 +25,7 @@ func DefineMacros(program *ast.Program, env *object.Environment) {
 
 func isMacroDefinition(node ast.Statement) bool {
 	letStatement, ok := node.(*ast.LetStatement)
-	if !ok {
+	if !ok || letStatement.Name == nil {
 		return false
 	}
 	_, ok = letStatement.Value.(*ast.MacroLiteral)
@@ -44,8 +44,14 @@ func addMacro(node ast.Statement, env *object.Environment) {
 	env.Set(letStatement.Name.Value, macro)
 }
 
-func ExpandMacros(program ast.Node, env *object.Environment) ast.Node {
-	return ast.Modify(program, func(node ast.Node) ast.Node {
+// ExpandMacros 展开程序中的宏调用。宏调用参数个数不对、宏求值出错或者没有返回 QUOTE 时，
+// 停止展开并返回带有位置的错误
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		if err != nil {
+			return node
+		}
 		callExpression, ok := node.(*ast.CallExpression)
 		if !ok {
 			return node
@@ -57,17 +63,29 @@ func ExpandMacros(program ast.Node, env *object.Environment) ast.Node {
 		}
 
 		args := quoteArgs(callExpression)
+		if len(args) != len(macro.Parameters) {
+			err = newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=%d", len(args), len(macro.Parameters))
+			err.Pos = callExpression.Pos()
+			return node
+		}
 		evalEnv := extendMacroEnv(macro, args)
 
-		evaluated := Eval(macro.Body, evalEnv)
+		evaluated := unwrapReturnValue(Eval(macro.Body, evalEnv))
+		if e, ok := evaluated.(*object.Error); ok {
+			err = e
+			return node
+		}
 
 		quote, ok := evaluated.(*object.Quote)
 		if !ok {
-			panic("we only support returning AST-node from macros")
+			err = newError(object.TYPE_ERROR, "macro must return QUOTE, got %s", evaluated.Type())
+			err.Pos = callExpression.Pos()
+			return node
 		}
 
 		return quote.Node
 	})
+	return expanded, err
 }
 
 func isMacroCall(exp *ast.CallExpression, env *object.Environment) (*object.Marco, bool) {
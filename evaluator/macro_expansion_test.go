@@ -74,7 +74,10 @@ func TestExpandMacros(t *testing.T) {
 		program := testParseProgram(tt.input)
 		env := object.NewEnvironment()
 		DefineMacros(program, env)
-		expanded := ExpandMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("ExpandMacros returned error: %s", err.Inspect())
+		}
 
 		if expanded.String() != expected.String() {
 			t.Errorf("not equal, want=%q, got=%q", expected.String(), expanded.String())
@@ -83,3 +86,45 @@ func TestExpandMacros(t *testing.T) {
 	}
 
 }
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"let m = macro(x) { x };\nm(1, 2);",
+			"ERROR: 2:1: ArgumentError: wrong number of arguments. got=2, want=1",
+		},
+		{
+			"let m = macro() { 1 };\nm();",
+			"ERROR: 2:1: TypeError: macro must return QUOTE, got INTEGER",
+		},
+		{
+			"let m = macro() { foo };\nm();",
+			"ERROR: 1:19: NameError: identifier not found: foo",
+		},
+		{
+			"let m = macro() { quote(unquote([1]) + 1) };\nm();",
+			"ERROR: 1:25: TypeError: cannot unquote ARRAY",
+		},
+		{
+			"let m = macro() { quote(unquote(9223372036854775807 + 1)) };\nm();",
+			"ERROR: 1:25: TypeError: cannot unquote integer out of int64 range: 9223372036854775808",
+		},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		program := testParseProgram(tt.input)
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("expected error for %q", tt.input)
+			continue
+		}
+		if err.Inspect() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, err.Inspect())
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fengshux/monkey/ast"
+	"github.com/fengshux/monkey/object"
+)
+
+// Method 是内置类型的方法，receiver 是 . 左侧的值，args 是调用时的实参
+type Method func(receiver object.Object, args ...object.Object) object.Object
+
+// methods 是按类型登记的方法表，arr.push(v) 调用 methods[ARRAY]["push"](arr, v)
+var methods = map[object.ObjectType]map[string]Method{
+	object.ARRAY_OBJ: {
+		"len":      buildinMethod(buildinLen, 0),
+		"first":    buildinMethod(buildinFirst, 0),
+		"last":     buildinMethod(buildinLast, 0),
+		"rest":     buildinMethod(buildinRest, 0),
+		"push":     buildinMethod(buildinPush, 1),
+		"join":     arrayJoin,
+		"contains": arrayContains,
+	},
+	object.STRING_OBJ: {
+		"len":      buildinMethod(buildinLen, 0),
+		"upper":    stringMethod(strings.ToUpper),
+		"lower":    stringMethod(strings.ToLower),
+		"trim":     stringMethod(strings.TrimSpace),
+		"split":    stringSplit,
+		"contains": stringContains,
+	},
+	object.HASH_OBJ: {
+		"len":    hashLen,
+		"keys":   hashKeys,
+		"values": hashValues,
+		"has":    hashHas,
+	},
+}
+
+// RegisterMethod 为类型 t 登记名为 name 的方法，已有的同名方法会被替换
+func RegisterMethod(t object.ObjectType, name string, fn Method) {
+	if methods[t] == nil {
+		methods[t] = make(map[string]Method)
+	}
+	methods[t][name] = fn
+}
+
+// evalMemberExpression 求值 obj.name：模块导出的名字、哈希中键为 name 的字段，
+// 其次是按 obj 的类型登记的方法，方法和 obj 绑定后作为内置函数返回。哈希中不存在的字段为 null
+func evalMemberExpression(node *ast.MemberExpression, env *object.Environment) object.Object {
+	obj := Eval(node.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	name := node.Property.Value
+
+	switch obj := obj.(type) {
+	case *object.Module:
+		return evalModuleMember(obj, name)
+	case *object.Hash:
+		if val := hashField(obj, name); val != nil {
+			return val
+		}
+	}
+
+	if method, ok := methods[obj.Type()][name]; ok {
+		return &object.Buildin{Fn: func(args ...object.Object) object.Object {
+			return method(obj, args...)
+		}}
+	}
+	if obj.Type() == object.HASH_OBJ {
+		return NULL
+	}
+	return newError(object.NAME_ERROR, "%s has no member: %s", obj.Type(), name)
+}
+
+// evalMemberAssignment 求值 obj.name = value，只有哈希的字段可以赋值
+func evalMemberAssignment(node *ast.MemberExpression, value ast.Expression, env *object.Environment) object.Object {
+	obj := Eval(node.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	val := Eval(value, env)
+	if isError(val) {
+		return val
+	}
+
+	switch obj.(type) {
+	case *object.Hash:
+		return evalIndexAssignment(obj, &object.String{Value: node.Property.Value}, val)
+	case *object.Module:
+		return newError(object.TYPE_ERROR, "cannot assign to module member: %s", node.Property.Value)
+	default:
+		return newError(object.TYPE_ERROR, "cannot assign to member of %s", obj.Type())
+	}
+}
+
+// buildinMethod 把以接收者为第一个参数的内置函数包装成方法，n 是除接收者以外的参数个数
+func buildinMethod(fn object.BuildinFunction, n int) Method {
+	return func(receiver object.Object, args ...object.Object) object.Object {
+		if len(args) != n {
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=%d", len(args), n)
+		}
+		return fn(append([]object.Object{receiver}, args...)...)
+	}
+}
+
+func stringMethod(fn func(string) string) Method {
+	return func(receiver object.Object, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=0", len(args))
+		}
+		return &object.String{Value: fn(receiver.(*object.String).Value)}
+	}
+}
+
+// stringArg 检查方法只有一个字符串参数并返回它
+func stringArg(method string, args []object.Object) (string, *object.Error) {
+	if len(args) != 1 {
+		return "", newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return "", newError(object.TYPE_ERROR, "argument to `%s` must be STRING, got %s", method, args[0].Type())
+	}
+	return str.Value, nil
+}
+
+func stringSplit(receiver object.Object, args ...object.Object) object.Object {
+	sep, err := stringArg("split", args)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(receiver.(*object.String).Value, sep)
+	elements := make([]object.Object, len(parts))
+	for i, part := range parts {
+		elements[i] = &object.String{Value: part}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func stringContains(receiver object.Object, args ...object.Object) object.Object {
+	sub, err := stringArg("contains", args)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBooleanObject(strings.Contains(receiver.(*object.String).Value, sub))
+}
+
+// arrayJoin 用分隔符连接数组的元素，字符串元素使用其内容，其他元素使用 Inspect 的结果
+func arrayJoin(receiver object.Object, args ...object.Object) object.Object {
+	sep, err := stringArg("join", args)
+	if err != nil {
+		return err
+	}
+	elements := receiver.(*object.Array).Elements
+	parts := make([]string, len(elements))
+	for i, el := range elements {
+		if str, ok := el.(*object.String); ok {
+			parts[i] = str.Value
+		} else {
+			parts[i] = el.Inspect()
+		}
+	}
+	return &object.String{Value: strings.Join(parts, sep)}
+}
+
+func arrayContains(receiver object.Object, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	for _, el := range receiver.(*object.Array).Elements {
+		if objectsEqual(el, args[0]) {
+			return TRUE
+		}
+	}
+	return FALSE
+}
+
+func hashLen(receiver object.Object, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+	return &object.Integer{Value: int64(len(receiver.(*object.Hash).Pairs))}
+}
+
+// sortedPairs 返回按键的 Inspect 结果排序的键值对，使 keys() 和 values() 的顺序确定
+func sortedPairs(hash *object.Hash) []object.HashPair {
+	pairs := make([]object.HashPair, 0, len(hash.Pairs))
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key.Inspect() < pairs[j].Key.Inspect()
+	})
+	return pairs
+}
+
+func hashKeys(receiver object.Object, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+	pairs := sortedPairs(receiver.(*object.Hash))
+	keys := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.Key
+	}
+	return &object.Array{Elements: keys}
+}
+
+func hashValues(receiver object.Object, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+	pairs := sortedPairs(receiver.(*object.Hash))
+	values := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		values[i] = pair.Value
+	}
+	return &object.Array{Elements: values}
+}
+
+func hashHas(receiver object.Object, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	key, ok := args[0].(object.Hashable)
+	if !ok {
+		return newError(object.TYPE_ERROR, "unhashable as hash key: %s", args[0].Type())
+	}
+	_, ok = receiver.(*object.Hash).Pairs[key.HashKey()]
+	return nativeBoolToBooleanObject(ok)
+}
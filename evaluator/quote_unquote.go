@@ -9,13 +9,18 @@ import (
 )
 
 func quote(node ast.Node, env *object.Environment) object.Object {
-	node = evalUnquoteCalls(node, env)
+	node, err := evalUnquoteCalls(node, env)
+	if err != nil {
+		return err
+	}
 	return &object.Quote{Node: node}
 }
 
-func evalUnquoteCalls(quoted ast.Node, env *object.Environment) ast.Node {
-	return ast.Modify(quoted, func(node ast.Node) ast.Node {
-		if !isUnquoteCall(node) {
+// evalUnquoteCalls 替换 quoted 中的 unquote 调用，返回第一个求值或者转换失败的错误
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+	node := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		if err != nil || !isUnquoteCall(node) {
 			return node
 		}
 
@@ -28,8 +33,25 @@ func evalUnquoteCalls(quoted ast.Node, env *object.Environment) ast.Node {
 			return node
 		}
 		unquoted := Eval(call.Arguments[0], env)
-		return convertObjectToAstNode(unquoted)
+		if e, ok := unquoted.(*object.Error); ok {
+			err = e
+			return node
+		}
+		if n, ok := unquoted.(*object.BigInt); ok {
+			// 整数字面量只能表示 int64 范围内的整数
+			err = newError(object.TYPE_ERROR, "cannot unquote integer out of int64 range: %s", n.Inspect())
+			err.Pos = call.Pos()
+			return node
+		}
+		converted := convertObjectToAstNode(unquoted)
+		if converted == nil {
+			err = newError(object.TYPE_ERROR, "cannot unquote %s", unquoted.Type())
+			err.Pos = call.Pos()
+			return node
+		}
+		return converted
 	})
+	return node, err
 }
 
 func isUnquoteCall(node ast.Node) bool {
@@ -51,6 +73,15 @@ func convertObjectToAstNode(obj object.Object) ast.Node {
 			Token: t,
 			Value: obj.Value,
 		}
+	case *object.Float:
+		t := token.Token{
+			Type:    token.FLOAT,
+			Literal: obj.Inspect(),
+		}
+		return &ast.FloatLiteral{
+			Token: t,
+			Value: obj.Value,
+		}
 	case *object.Boolean:
 		var t token.Token
 		if obj.Value {
@@ -59,6 +90,11 @@ func convertObjectToAstNode(obj object.Object) ast.Node {
 			t = token.Token{Type: token.FALSE, Literal: "false"}
 		}
 		return &ast.Boolean{Token: t, Value: obj.Value}
+	case *object.String:
+		t := token.Token{Type: token.STRING, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}
+	case *object.Null:
+		return &ast.NullLiteral{Token: token.Token{Type: token.NULL, Literal: "null"}}
 	case *object.Quote:
 		return obj.Node
 	default:
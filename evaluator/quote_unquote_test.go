@@ -85,6 +85,10 @@ func TestQuoteUnquote(t *testing.T) {
 			`quote(unquote(true == false))`,
 			`false`,
 		},
+		{
+			`quote(match (unquote(1 + 1)) { n if n > unquote(2 * 2) => unquote(5 * 1), _ => 0 })`,
+			`match (2) {n if (n > 4) => 5, _ => 0}`,
+		},
 		{
 			`quote(unquote(quote(4 + 4)))`,
 			`4 + 4`,
@@ -1,23 +1,109 @@
 package lexer
 
-import "github.com/fengshux/monkey/token"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// Mode 控制词法分析器的行为
+type Mode uint
+
+const (
+	ScanComments Mode = 1 << iota // 将注释作为 COMMENT 词法单元返回，而不是跳过
+)
+
+// ErrorHandler 在词法分析遇到错误时被调用，pos 为出错的位置
+type ErrorHandler func(pos token.Position, msg string)
 
 type Lexer struct {
 	input        string
-	position     int
-	readPosition int
-	ch           byte
+	reader       *bufio.Reader // 不为nil时从reader中逐个字符读取，而不是从input中读取
+	buf          []byte        // 从reader读取时，当前词法单元开始到当前字符为止读到的内容
+	filename     string
+	mode         Mode
+	errorHandler ErrorHandler
+	position     int  // 当前字符ch的字节偏移量
+	readPosition int  // 下一个字符的字节偏移量
+	ch           rune // 当前字符，0 表示输入结束
+	width        int  // 当前字符ch的字节数
+	started      bool // 是否已经读入第一个字符
+	atEOF        bool
+	interps      []int // 每层未结束的字符串插值 ${ 中尚未闭合的 { 的数量
+	line         int   // 当前字符ch所在的行
+	column       int   // 当前字符ch所在的列，按字符而不是字节计数
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
-	l.readChar()
-	return l
+	return NewFile("", input)
+}
+
+// NewFile 创建一个词法分析器，filename 会记录在每个词法单元的位置信息中
+func NewFile(filename, input string) *Lexer {
+	return &Lexer{input: input, filename: filename, line: 1}
+}
+
+// NewReader 创建一个从r中读取源代码的词法分析器，得到的词法单元和 New 相同。
+// 它只是为了使用方便，逐个字符读取比 New 慢，内存分配也更多（见 BenchmarkLexerReader），
+// 源代码可以整个读入时应该使用 New
+func NewReader(r io.Reader) *Lexer {
+	return NewFileReader("", r)
+}
+
+// NewFileReader 和 NewReader 相同，filename 会记录在每个词法单元的位置信息中
+func NewFileReader(filename string, r io.Reader) *Lexer {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReaderSize(r, 64*1024)
+	}
+	return &Lexer{reader: br, filename: filename, line: 1}
+}
+
+// SetMode 设置词法分析器的模式
+func (l *Lexer) SetMode(mode Mode) {
+	l.mode = mode
+}
+
+// SetErrorHandler 设置词法错误的处理函数，遇到错误时仍会返回 ILLEGAL 词法单元
+func (l *Lexer) SetErrorHandler(h ErrorHandler) {
+	l.errorHandler = h
+}
+
+func (l *Lexer) error(pos token.Position, format string, args ...interface{}) {
+	if l.errorHandler != nil {
+		l.errorHandler(pos, fmt.Sprintf(format, args...))
+	}
 }
 
 func (l *Lexer) NextToken() token.Token {
+	// 第一个字符推迟到这里读取，使 SetErrorHandler 设置的处理函数也能收到它的错误
+	if !l.started {
+		l.started = true
+		l.readChar()
+	}
+	for {
+		l.skipWhitespace()
+		l.resetBuffer()
+		pos, mark := l.pos(), l.mark()
+		tok := l.readToken()
+		tok.Pos = pos
+		tok.Raw = l.rawFrom(mark, tok.Literal)
+
+		if tok.Type == token.COMMENT && l.mode&ScanComments == 0 {
+			continue
+		}
+		return tok
+	}
+}
+
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
-	l.skipWhitespace()
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -25,6 +111,11 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			tok.Literal = string(ch) + string(l.ch)
 			tok.Type = token.EQ
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.ARROW
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
@@ -33,9 +124,49 @@ func (l *Lexer) NextToken() token.Token {
 	case '-':
 		tok = newToken(token.MINUS, l.ch)
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.POWER
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '&':
+		if l.peekChar() == '&' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.AND
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.OR
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		switch l.peekChar() {
+		case '/':
+			tok.Type = token.COMMENT
+			tok.Literal = l.readLineComment()
+			return tok
+		case '*':
+			return l.readBlockComment()
+		default:
+			tok = newToken(token.SLASH, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -46,9 +177,33 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '<' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.SHL
+		} else if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.LT_EQ
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.SHR
+		} else if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.GT_EQ
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case '(':
@@ -60,29 +215,57 @@ func (l *Lexer) NextToken() token.Token {
 	case ':':
 		tok = newToken(token.COLON, l.ch)
 	case '{':
+		if n := len(l.interps); n > 0 {
+			l.interps[n-1]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.interps); n > 0 {
+			if l.interps[n-1] == 0 {
+				// 字符串插值结束，继续读取字符串剩余的部分
+				l.interps = l.interps[:n-1]
+				return l.readStringPart(false)
+			}
+			l.interps[n-1]--
+		}
 		tok = newToken(token.RBRACE, l.ch)
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		return l.readStringPart(true)
+	case '`':
+		return l.readRawString()
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
+	case '.':
+		if l.peekChar() == '.' {
+			pos := l.pos()
+			l.readChar()
+			if l.peekChar() == '.' {
+				l.readChar()
+				tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+			} else {
+				l.error(pos, "illegal character sequence \"..\"")
+				tok = token.Token{Type: token.ILLEGAL, Literal: ".."}
+			}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
-			return tok
+			return l.readNumber()
 		} else {
+			// 非法的UTF-8编码已经在readChar中报告过了
+			if l.ch != utf8.RuneError || l.width != 1 {
+				l.error(l.pos(), "illegal character %q", l.ch)
+			}
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
@@ -91,47 +274,322 @@ func (l *Lexer) NextToken() token.Token {
 }
 
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
+	// 已经读到输入末尾
+	if l.atEOF {
+		return
+	}
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 1
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.column += 1
 	}
+
 	l.position = l.readPosition
-	l.readPosition += 1
+	r, width := l.decodeNext()
+	if width == 0 {
+		l.ch = 0
+		l.width = 0
+		l.atEOF = true
+		return
+	}
+
+	l.ch = r
+	l.width = width
+	l.readPosition += width
+	if r == utf8.RuneError && width == 1 {
+		l.error(l.pos(), "invalid UTF-8 encoding")
+	}
+}
+
+// decodeNext 读取下一个字符，width 为0表示输入已经结束
+func (l *Lexer) decodeNext() (rune, int) {
+	if l.reader == nil {
+		if l.readPosition >= len(l.input) {
+			return 0, 0
+		}
+		return utf8.DecodeRuneInString(l.input[l.readPosition:])
+	}
+
+	c, err := l.reader.ReadByte()
+	if err != nil {
+		if err != io.EOF {
+			l.error(l.pos(), "read error: %s", err)
+		}
+		return 0, 0
+	}
+	// 大部分源代码都是ASCII字符，不需要解码
+	if c < utf8.RuneSelf {
+		l.buf = append(l.buf, c)
+		return rune(c), 1
+	}
+
+	l.reader.UnreadByte()
+	b, _ := l.reader.Peek(utf8.UTFMax)
+	r, width := utf8.DecodeRune(b)
+	l.buf = append(l.buf, b[:width]...)
+	l.reader.Discard(width)
+	return r, width
 }
 
-func (l *Lexer) peekChar() byte {
+// resetBuffer 在读取新的词法单元之前丢弃已经读过的内容，只保留当前字符
+func (l *Lexer) resetBuffer() {
+	if l.reader == nil {
+		return
+	}
+	n := copy(l.buf, l.buf[len(l.buf)-l.width:])
+	l.buf = l.buf[:n]
+}
+
+// mark 返回当前字符的偏移量，配合 textFrom 获取词法单元的原文
+func (l *Lexer) mark() int {
+	if l.reader == nil {
+		return l.position
+	}
+	return len(l.buf) - l.width
+}
+
+// textFrom 返回从 mark 到当前字符（不包含）之间的原文
+func (l *Lexer) textFrom(mark int) string {
+	if l.reader == nil {
+		return l.input[mark:l.position]
+	}
+	return string(l.buf[mark : len(l.buf)-l.width])
+}
+
+// rawFrom 返回从 mark 到当前字符（不包含）之间的原文，原文与 literal 相同时返回空字符串
+func (l *Lexer) rawFrom(mark int, literal string) string {
+	if l.reader == nil {
+		if raw := l.input[mark:l.position]; raw != literal {
+			return raw
+		}
+		return ""
+	}
+	if raw := l.buf[mark : len(l.buf)-l.width]; string(raw) != literal {
+		return string(raw)
+	}
+	return ""
+}
+
+// pos 返回当前字符ch的位置
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+func (l *Lexer) peekChar() rune {
+	if l.reader != nil {
+		b, _ := l.reader.Peek(utf8.UTFMax)
+		if len(b) == 0 {
+			return 0
+		}
+		r, _ := utf8.DecodeRune(b)
+		return r
+	}
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	r, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return r
 }
 
+// readIdentifier 读取标识符，标识符以字母开头，之后可以是字母或数字
 func (l *Lexer) readIdentifier() string {
-	position := l.position
-	for isLetter(l.ch) {
+	position := l.mark()
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return l.textFrom(position)
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position
-	for isDigit(l.ch) {
+// readNumber 读取整数或浮点数，浮点数包含小数部分或指数部分，如 3.14、1e10、2.5e-3。
+// 整数可以带有 0x、0o、0b 前缀，数字之间可以用 _ 分隔，数字是否合法由语法分析器检查
+func (l *Lexer) readNumber() token.Token {
+	pos := l.pos()
+	position := l.mark()
+	tokenType := token.TokenType(token.INT)
+
+	if l.ch == '0' && strings.ContainsRune("xXoObB", l.peekChar()) {
+		l.readChar()
 		l.readChar()
+		for isLetter(l.ch) || isDigit(l.ch) {
+			l.readChar()
+		}
+		return token.Token{Type: tokenType, Literal: l.textFrom(position)}
 	}
-	return l.input[position:l.position]
+
+	l.readDigits()
+	// 小数点后必须是数字，1.foo 中的点不属于数字
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.ch) {
+			l.error(pos, "exponent has no digits")
+		}
+		l.readDigits()
+	}
+	return token.Token{Type: tokenType, Literal: l.textFrom(position)}
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
-	for {
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+	}
+}
+
+// readStringPart 读取双引号字符串并处理其中的转义字符，字符串不能跨行。
+// 字符串中的 ${ 开始一段插值，插值之后的部分在遇到对应的 } 时继续读取。
+// head 为true时当前字符是开头的 "，否则是结束插值的 }
+func (l *Lexer) readStringPart(head bool) token.Token {
+	pos := l.pos()
+	position := l.mark()
+	var out strings.Builder
+
+	l.readChar()
+	for l.ch != '"' {
+		if l.ch == 0 || l.ch == '\n' {
+			l.error(pos, "string literal not terminated")
+			return token.Token{Type: token.ILLEGAL, Literal: l.textFrom(position)}
+		}
+		if l.ch == '\\' {
+			l.readEscape(&out)
+			continue
 		}
+		if l.ch == '$' && l.peekChar() == '{' {
+			l.readChar()
+			l.readChar()
+			l.interps = append(l.interps, 0)
+			if head {
+				return token.Token{Type: token.INTERP_HEAD, Literal: out.String()}
+			}
+			return token.Token{Type: token.INTERP_MID, Literal: out.String()}
+		}
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+	l.readChar()
+	if head {
+		return token.Token{Type: token.STRING, Literal: out.String()}
+	}
+	return token.Token{Type: token.INTERP_TAIL, Literal: out.String()}
+}
+
+// readEscape 读取以 \ 开头的转义字符，并将其代表的字符写入out
+func (l *Lexer) readEscape(out *strings.Builder) {
+	pos := l.pos()
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '0':
+		out.WriteByte(0)
+	case '\\', '"', '\'', '$':
+		out.WriteRune(l.ch)
+	case 'u':
+		l.readUnicodeEscape(pos, out)
+		return
+	case 0, '\n':
+		// 交给readString报告字符串未结束
+		return
+	default:
+		l.error(pos, "unknown escape sequence \\%c", l.ch)
+	}
+	l.readChar()
+}
+
+// readUnicodeEscape 读取 \u{XXXX} 形式的转义字符，当前字符为u
+func (l *Lexer) readUnicodeEscape(pos token.Position, out *strings.Builder) {
+	l.readChar()
+	if l.ch != '{' {
+		l.error(pos, "invalid unicode escape, expected \\u{XXXX}")
+		return
+	}
+	l.readChar()
+
+	position := l.mark()
+	for isHexDigit(l.ch) {
+		l.readChar()
+	}
+	digits := l.textFrom(position)
+	if l.ch != '}' || len(digits) == 0 || len(digits) > 6 {
+		l.error(pos, "invalid unicode escape, expected \\u{XXXX}")
+		return
+	}
+	l.readChar()
+
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	if code > 0x10FFFF || (code >= 0xD800 && code <= 0xDFFF) {
+		l.error(pos, "escape sequence \\u{%s} is not a valid unicode code point", digits)
+		return
+	}
+	out.WriteRune(rune(code))
+}
+
+// readRawString 读取反引号包围的原始字符串，不处理转义字符，可以跨行
+func (l *Lexer) readRawString() token.Token {
+	pos := l.pos()
+	position := l.mark()
+
+	l.readChar()
+	for l.ch != '`' {
+		if l.ch == 0 {
+			l.error(pos, "raw string literal not terminated")
+			return token.Token{Type: token.ILLEGAL, Literal: l.textFrom(position)}
+		}
+		l.readChar()
+	}
+	l.readChar()
+
+	// 和Go一样，原始字符串中的回车符会被丢弃
+	literal := l.textFrom(position)
+	literal = literal[1 : len(literal)-1]
+	return token.Token{Type: token.STRING, Literal: strings.ReplaceAll(literal, "\r", "")}
+}
+
+// readLineComment 读取 // 注释，不包含行尾的换行符
+func (l *Lexer) readLineComment() string {
+	position := l.mark()
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return l.textFrom(position)
+}
+
+// readBlockComment 读取 /* */ 注释，未闭合的注释返回 ILLEGAL 词法单元
+func (l *Lexer) readBlockComment() token.Token {
+	pos := l.pos()
+	position := l.mark()
+	l.readChar()
+	l.readChar()
+	for {
+		if l.ch == 0 {
+			l.error(pos, "comment not terminated")
+			return token.Token{Type: token.ILLEGAL, Literal: l.textFrom(position)}
+		}
+		if l.ch == '*' && l.peekChar() == '/' {
+			l.readChar()
+			l.readChar()
+			return token.Token{Type: token.COMMENT, Literal: l.textFrom(position)}
+		}
+		l.readChar()
 	}
-	return l.input[position:l.position]
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -140,14 +598,31 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter 判断是否为Unicode字母或下划线，例如中文字符也可以作为标识符
+func isLetter(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
 }
 
-func isDigit(ch byte) bool {
+// isDigit 只接受ASCII数字，数字字面量不支持其它语言的数字字符
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
+	if ch < utf8.RuneSelf {
+		return token.Token{Type: tokenType, Literal: asciiStrings[ch]}
+	}
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
+
+// asciiStrings 是单个ASCII字符的字符串，单字符的词法单元复用它们而不必每次分配内存
+var asciiStrings = func() (s [utf8.RuneSelf]string) {
+	for i := range s {
+		s[i] = string(rune(i))
+	}
+	return s
+}()
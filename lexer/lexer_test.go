@@ -1,7 +1,9 @@
 package lexer
 
 import (
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/fengshux/monkey/token"
 )
@@ -17,7 +19,7 @@ func TestNextToken(t *testing.T) {
 		
 		let result = add(five, ten);
 		
-		!-/*5;
+		!-/ *5;
 		5 < 10 > 5;
 
 		if (5<10) {
@@ -157,3 +159,649 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPosition(t *testing.T) {
+	input := "let x = 5;\n  x == 10;"
+
+	tests := []struct {
+		expectType token.TokenType
+		expectPos  token.Position
+		expectEnd  token.Position
+	}{
+		{token.LET, token.Position{Filename: "test.mk", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "test.mk", Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Filename: "test.mk", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "test.mk", Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Filename: "test.mk", Offset: 6, Line: 1, Column: 7}, token.Position{Filename: "test.mk", Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Filename: "test.mk", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "test.mk", Offset: 9, Line: 1, Column: 10}},
+		{token.SEMICOLON, token.Position{Filename: "test.mk", Offset: 9, Line: 1, Column: 10}, token.Position{Filename: "test.mk", Offset: 10, Line: 1, Column: 11}},
+		{token.IDENT, token.Position{Filename: "test.mk", Offset: 13, Line: 2, Column: 3}, token.Position{Filename: "test.mk", Offset: 14, Line: 2, Column: 4}},
+		{token.EQ, token.Position{Filename: "test.mk", Offset: 15, Line: 2, Column: 5}, token.Position{Filename: "test.mk", Offset: 17, Line: 2, Column: 7}},
+		{token.INT, token.Position{Filename: "test.mk", Offset: 18, Line: 2, Column: 8}, token.Position{Filename: "test.mk", Offset: 20, Line: 2, Column: 10}},
+		{token.SEMICOLON, token.Position{Filename: "test.mk", Offset: 20, Line: 2, Column: 10}, token.Position{Filename: "test.mk", Offset: 21, Line: 2, Column: 11}},
+		{token.EOF, token.Position{Filename: "test.mk", Offset: 21, Line: 2, Column: 11}, token.Position{Filename: "test.mk", Offset: 21, Line: 2, Column: 11}},
+	}
+
+	l := NewFile("test.mk", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectType, tok.Type)
+		}
+
+		if tok.Pos != tt.expectPos {
+			t.Fatalf("tests[%d] - pos wrong. expected=%+v, got=%+v",
+				i, tt.expectPos, tok.Pos)
+		}
+
+		if tok.End() != tt.expectEnd {
+			t.Fatalf("tests[%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectEnd, tok.End())
+		}
+	}
+
+	// 原文和 Literal 不同的词法单元，结束位置按原文推算
+	input = "\"a\\t你\" `r\nxy` \"p ${y} q\" /* c\n */"
+	rangeTests := []struct {
+		expectType token.TokenType
+		expectPos  string
+		expectEnd  string
+		endOffset  int
+	}{
+		{token.STRING, "1:1", "1:7", 8},
+		{token.STRING, "1:8", "2:4", 15},
+		{token.INTERP_HEAD, "2:5", "2:10", 21},
+		{token.IDENT, "2:10", "2:11", 22},
+		{token.INTERP_TAIL, "2:11", "2:15", 26},
+		{token.COMMENT, "2:16", "3:4", 35},
+		{token.EOF, "3:4", "3:4", 35},
+	}
+
+	for _, l := range []*Lexer{New(input), NewReader(strings.NewReader(input))} {
+		l.SetMode(ScanComments)
+		for i, tt := range rangeTests {
+			tok := l.NextToken()
+			if tok.Type != tt.expectType {
+				t.Fatalf("rangeTests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectType, tok.Type)
+			}
+			if tok.Pos.String() != tt.expectPos || tok.End().String() != tt.expectEnd || tok.End().Offset != tt.endOffset {
+				t.Errorf("rangeTests[%d] - range wrong. expected=%s-%s (%d), got=%s-%s (%d)",
+					i, tt.expectPos, tt.expectEnd, tt.endOffset, tok.Pos, tok.End(), tok.End().Offset)
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 5; // trailing
+/* block
+   comment */ x / 2;
+/* unterminated`
+
+	type expect struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}
+
+	tests := []struct {
+		mode     Mode
+		expected []expect
+	}{
+		{
+			0,
+			[]expect{
+				{token.LET, "let"},
+				{token.IDENT, "x"},
+				{token.ASSIGN, "="},
+				{token.INT, "5"},
+				{token.SEMICOLON, ";"},
+				{token.IDENT, "x"},
+				{token.SLASH, "/"},
+				{token.INT, "2"},
+				{token.SEMICOLON, ";"},
+				{token.ILLEGAL, "/* unterminated"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			ScanComments,
+			[]expect{
+				{token.COMMENT, "// leading comment"},
+				{token.LET, "let"},
+				{token.IDENT, "x"},
+				{token.ASSIGN, "="},
+				{token.INT, "5"},
+				{token.SEMICOLON, ";"},
+				{token.COMMENT, "// trailing"},
+				{token.COMMENT, "/* block\n   comment */"},
+				{token.IDENT, "x"},
+				{token.SLASH, "/"},
+				{token.INT, "2"},
+				{token.SEMICOLON, ";"},
+				{token.ILLEGAL, "/* unterminated"},
+				{token.EOF, ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		l := New(input)
+		l.SetMode(tt.mode)
+
+		for i, e := range tt.expected {
+			tok := l.NextToken()
+
+			if tok.Type != e.expectType {
+				t.Fatalf("mode %d tests[%d] - tokentype wrong. expected=%q, got=%q",
+					tt.mode, i, e.expectType, tok.Type)
+			}
+
+			if tok.Literal != e.expectLiteral {
+				t.Fatalf("mode %d tests[%d] - literal wrong. expected=%q, got=%q",
+					tt.mode, i, e.expectLiteral, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectLiteral string
+	}{
+		{`"a\"b"`, `a"b`},
+		{`"line\nnext\ttab\r"`, "line\nnext\ttab\r"},
+		{`"back\\slash"`, `back\slash`},
+		{`"\u{48}\u{e9}\u{1F600}"`, "Hé😀"},
+		{"`raw \\n string`", `raw \n string`},
+		{"`multi\r\nline`", "multi\nline"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		l.SetErrorHandler(func(pos token.Position, msg string) {
+			t.Errorf("tests[%d] - unexpected error at %s: %s", i, pos, msg)
+		})
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, token.STRING, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectType  token.TokenType
+		expectError string
+	}{
+		{`"abc`, token.ILLEGAL, "1:1: string literal not terminated"},
+		{"let s = \"abc\n\";", token.ILLEGAL, "1:9: string literal not terminated"},
+		{"`abc", token.ILLEGAL, "1:1: raw string literal not terminated"},
+		{`"a\qb"`, token.STRING, `1:3: unknown escape sequence \q`},
+		{`"\u{110000}"`, token.STRING, `1:2: escape sequence \u{110000} is not a valid unicode code point`},
+		{`"\u12"`, token.STRING, `1:2: invalid unicode escape, expected \u{XXXX}`},
+	}
+
+	for i, tt := range tests {
+		var errors []string
+		l := New(tt.input)
+		l.SetErrorHandler(func(pos token.Position, msg string) {
+			errors = append(errors, pos.String()+": "+msg)
+		})
+
+		var tok token.Token
+		for tok = l.NextToken(); tok.Type != tt.expectType; tok = l.NextToken() {
+			if tok.Type == token.EOF {
+				t.Fatalf("tests[%d] - no %s token found", i, tt.expectType)
+			}
+		}
+
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 error. got=%v", i, errors)
+		}
+		if errors[0] != tt.expectError {
+			t.Errorf("tests[%d] - wrong error. expected=%q, got=%q",
+				i, tt.expectError, errors[0])
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `let 名字 = "你好，世界"; café_2 + 名字;`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+		expectColumn  int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENT, "名字", 5},
+		{token.ASSIGN, "=", 8},
+		{token.STRING, "你好，世界", 10},
+		{token.SEMICOLON, ";", 17},
+		{token.IDENT, "café_2", 19},
+		{token.PLUS, "+", 26},
+		{token.IDENT, "名字", 28},
+		{token.SEMICOLON, ";", 30},
+		{token.EOF, "", 31},
+	}
+
+	l := New(input)
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		t.Errorf("unexpected error at %s: %s", pos, msg)
+	})
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Column != tt.expectColumn {
+			t.Fatalf("tests[%d] - column wrong. expected=%d, got=%d",
+				i, tt.expectColumn, tok.Pos.Column)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	input := "let a = \xff;\n\"b\xfe\""
+
+	var errors []string
+	l := New(input)
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		errors = append(errors, pos.String()+": "+msg)
+	})
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	expected := []string{
+		"1:9: invalid UTF-8 encoding",
+		"2:3: invalid UTF-8 encoding",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%v", len(expected), errors)
+	}
+	for i, e := range expected {
+		if errors[i] != e {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, e, errors[i])
+		}
+	}
+
+	// 输入的第一个字符出错时，构造之后设置的处理函数也能收到错误
+	for _, l := range []*Lexer{New("\xff1"), NewReader(strings.NewReader("\xff1"))} {
+		errors = nil
+		l.SetErrorHandler(func(pos token.Position, msg string) {
+			errors = append(errors, pos.String()+": "+msg)
+		})
+		if tok := l.NextToken(); tok.Type != token.ILLEGAL {
+			t.Errorf("first token is not ILLEGAL. got=%q", tok.Type)
+		}
+		if len(errors) != 1 || errors[0] != "1:1: invalid UTF-8 encoding" {
+			t.Errorf("wrong errors for leading invalid byte. got=%q", errors)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `5 3.14 1e10 2.5E-3 7e+2 0.5 1.foo`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.INT, "5"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1e10"},
+		{token.FLOAT, "2.5E-3"},
+		{token.FLOAT, "7e+2"},
+		{token.FLOAT, "0.5"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.IDENT, "foo"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	input := `0xff 0o17 0b1010 1_000_000 0XdeadBEEF 1_000.5`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.INT, "0xff"},
+		{token.INT, "0o17"},
+		{token.INT, "0b1010"},
+		{token.INT, "1_000_000"},
+		{token.INT, "0XdeadBEEF"},
+		{token.FLOAT, "1_000.5"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestArithmeticAndBitwiseOperators(t *testing.T) {
+	input := `a % b ** c & d | e ^ ~f << g >> h < i > j`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.POWER, "**"},
+		{token.IDENT, "c"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "d"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "e"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "f"},
+		{token.SHL, "<<"},
+		{token.IDENT, "g"},
+		{token.SHR, ">>"},
+		{token.IDENT, "h"},
+		{token.LT, "<"},
+		{token.IDENT, "i"},
+		{token.GT, ">"},
+		{token.IDENT, "j"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestLogicalAndComparisonOperators(t *testing.T) {
+	input := `a && b || c <= d >= e & f | g`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "d"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "e"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "f"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "g"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestReaderLexer(t *testing.T) {
+	inputs := []string{
+		"let add = fn(x, y) { x + y; };\nadd(1, 2.5e3) >= 0x_ff && !done || a ** b;",
+		"// comment\n/* block\n comment */ `raw\nstring` \"esc\\n\\u{4F60}\"",
+		"let 名字 = \"你好\"; café_1 << 2",
+		"\"unterminated\nlet a = \xff; /* open",
+		"",
+	}
+
+	type result struct {
+		tokens []token.Token
+		errors []string
+	}
+
+	lex := func(l *Lexer) result {
+		var res result
+		l.SetMode(ScanComments)
+		l.SetErrorHandler(func(pos token.Position, msg string) {
+			res.errors = append(res.errors, pos.String()+": "+msg)
+		})
+		for {
+			tok := l.NextToken()
+			res.tokens = append(res.tokens, tok)
+			if tok.Type == token.EOF {
+				return res
+			}
+		}
+	}
+
+	for i, input := range inputs {
+		expected := lex(NewFile("test.mk", input))
+		actual := lex(NewFileReader("test.mk", iotest.OneByteReader(strings.NewReader(input))))
+
+		if len(actual.tokens) != len(expected.tokens) {
+			t.Fatalf("inputs[%d] - wrong number of tokens. expected=%d, got=%d",
+				i, len(expected.tokens), len(actual.tokens))
+		}
+		for j := range expected.tokens {
+			if actual.tokens[j] != expected.tokens[j] {
+				t.Errorf("inputs[%d] tokens[%d] - expected=%+v, got=%+v",
+					i, j, expected.tokens[j], actual.tokens[j])
+			}
+		}
+
+		if strings.Join(actual.errors, "\n") != strings.Join(expected.errors, "\n") {
+			t.Errorf("inputs[%d] - errors wrong. expected=%q, got=%q",
+				i, expected.errors, actual.errors)
+		}
+	}
+}
+
+func benchmarkSource() string {
+	chunk := `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+// compute a few values
+let values = [fib(1), fib(2), 3.14 * 2, "string with \n escape", 0xff];
+`
+	return strings.Repeat(chunk, 2000)
+}
+
+func BenchmarkLexerString(b *testing.B) {
+	src := benchmarkSource()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := New(src)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
+
+func BenchmarkLexerReader(b *testing.B) {
+	src := benchmarkSource()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := NewReader(strings.NewReader(src))
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"a ${x} b ${f({"k": 1}["k"])} c" "\${x}" "${"${y}"}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INTERP_HEAD, "a "},
+		{token.IDENT, "x"},
+		{token.INTERP_MID, " b "},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.LBRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "k"},
+		{token.RBRACKET, "]"},
+		{token.RPAREN, ")"},
+		{token.INTERP_TAIL, " c"},
+		{token.STRING, "${x}"},
+		{token.INTERP_HEAD, ""},
+		{token.INTERP_HEAD, ""},
+		{token.IDENT, "y"},
+		{token.INTERP_TAIL, ""},
+		{token.INTERP_TAIL, ""},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	var errors []string
+	l := New("fn(...rest) .. .")
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		errors = append(errors, pos.String()+": "+msg)
+	})
+
+	expected := []token.TokenType{token.FUNCTION, token.LPAREN, token.ELLIPSIS, token.IDENT,
+		token.RPAREN, token.ILLEGAL, token.DOT, token.EOF}
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+
+	expectedErrors := []string{`1:13: illegal character sequence ".."`}
+	if strings.Join(errors, "\n") != strings.Join(expectedErrors, "\n") {
+		t.Errorf("wrong errors. expected=%q, got=%q", expectedErrors, errors)
+	}
+}
+
+func TestMatchTokens(t *testing.T) {
+	input := `match (x) { null => 1, n >= 2 => n, _ => a == b }`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.NULL, "null"},
+		{token.ARROW, "=>"},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.IDENT, "n"},
+		{token.GT_EQ, ">="},
+		{token.INT, "2"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "n"},
+		{token.COMMA, ","},
+		{token.IDENT, "_"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "a"},
+		{token.EQ, "=="},
+		{token.IDENT, "b"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
@@ -9,6 +10,17 @@ import (
 )
 
 func main() {
+	// 带有文件参数时执行脚本，否则进入REPL
+	if len(os.Args) > 1 {
+		if err := repl.RunFile(os.Args[1], os.Stderr); err != nil {
+			if !errors.Is(err, repl.ErrParse) && !errors.Is(err, repl.ErrRuntime) {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -3,11 +3,21 @@ package object
 import (
 	"fmt"
 	"strings"
+
+	"github.com/fengshux/monkey/token"
 )
 
+// Importer 加载 import 引用的模块，pos 是 import 表达式的位置
+type Importer interface {
+	Import(path string, pos token.Position) Object
+}
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store    map[string]Object
+	consts   map[string]bool // 只读的绑定
+	outer    *Environment
+	depth    int      // 函数调用栈的深度
+	importer Importer // 求值 import 表达式时使用的加载器
 }
 
 func NewEnvironment() *Environment {
@@ -18,9 +28,33 @@ func NewEnvironment() *Environment {
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
+	env.depth = outer.depth
+	env.importer = outer.importer
 	return env
 }
 
+// NewCallEnvironment 创建函数调用的环境，outer 是函数定义时的环境，depth 是这次调用在调用栈中的深度
+func NewCallEnvironment(outer *Environment, depth int) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.depth = depth
+	return env
+}
+
+// SetImporter 设置 import 使用的模块加载器，之后创建的内层环境会继承它
+func (e *Environment) SetImporter(importer Importer) {
+	e.importer = importer
+}
+
+// Importer 返回 import 使用的模块加载器，没有设置时返回nil
+func (e *Environment) Importer() Importer {
+	return e.importer
+}
+
+// Depth 返回环境所在的函数调用的深度，顶层为0
+func (e *Environment) Depth() int {
+	return e.depth
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
 	if !ok && e.outer != nil {
@@ -35,6 +69,48 @@ func (e *Environment) Set(name string, obj Object) Object {
 	return obj
 }
 
+// SetConst 在当前作用域中创建只读的绑定
+func (e *Environment) SetConst(name string, obj Object) Object {
+	if e.consts == nil {
+		e.consts = make(map[string]bool)
+	}
+	e.consts[name] = true
+	return e.Set(name, obj)
+}
+
+// IsConst 返回name所指的绑定是否只读
+func (e *Environment) IsConst(name string) bool {
+	env := e.resolve(name)
+	return env != nil && env.consts[name]
+}
+
+// IsLocalConst 返回当前作用域中name的绑定是否只读，不查找外层作用域。
+// 内层作用域可以用 let、参数等遮蔽外层的常量，但不能重新声明同一作用域中的常量
+func (e *Environment) IsLocalConst(name string) bool {
+	return e.consts[name]
+}
+
+// Assign 沿着 outer 向外查找已经存在的变量并修改它的值，变量不存在时返回false。
+// Assign 不检查绑定是否只读，调用者需要先使用 IsConst 检查
+func (e *Environment) Assign(name string, obj Object) bool {
+	env := e.resolve(name)
+	if env == nil {
+		return false
+	}
+	env.store[name] = obj
+	return true
+}
+
+// resolve 返回定义了name的最内层环境，没有找到时返回nil
+func (e *Environment) resolve(name string) *Environment {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return env
+		}
+	}
+	return nil
+}
+
 func (e *Environment) String() string {
 	var out strings.Builder
 	out.WriteString("{")
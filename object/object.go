@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/fengshux/monkey/ast"
+	"github.com/fengshux/monkey/token"
 )
 
 type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -24,6 +29,24 @@ const (
 	HASH_OBJ         = "HASH"
 	QUOTE_OBJ        = "QUOTE"
 	MACRO_OBJ        = "MACRO"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
+	MODULE_OBJ       = "MODULE"
+)
+
+// 错误的种类，保存在 Error.Kind 中
+const (
+	GENERIC_ERROR    = "Error"
+	SYNTAX_ERROR     = "SyntaxError"
+	TYPE_ERROR       = "TypeError"
+	NAME_ERROR       = "NameError"
+	ARGUMENT_ERROR   = "ArgumentError"
+	INDEX_ERROR      = "IndexError"
+	CONST_ERROR      = "ConstError"
+	ARITHMETIC_ERROR = "ArithmeticError"
+	RECURSION_ERROR  = "RecursionError"
+	IMPORT_ERROR     = "ImportError"
+	INTERNAL_ERROR   = "InternalError"
 )
 
 type HashKey struct {
@@ -56,6 +79,55 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// BigInt 表示超出int64范围的整数，运算结果能放入int64时会变回Integer。
+// BigInt和Integer的类型都是INTEGER，值相同时它们相等且HashKey相同
+type BigInt struct {
+	Value *big.Int
+}
+
+func (*BigInt) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+
+func (b *BigInt) HashKey() HashKey {
+	if b.Value.IsInt64() {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(b.Value.Int64())}
+	}
+	h := fnv.New64a()
+	h.Write([]byte{byte(b.Value.Sign() + 1)})
+	h.Write(b.Value.Bytes())
+	return HashKey{Type: INTEGER_OBJ, Value: h.Sum64()}
+}
+
+type Float struct {
+	Value float64
+}
+
+func (*Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// Inspect 输出的字符串可以重新被解析为相同的浮点数，整数值会带上 .0 以区别于整数
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// HashKey 整数值的浮点数和对应的整数拥有相同的HashKey，因为它们比较时相等
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && f.Value >= math.MinInt64 && f.Value < math.MaxInt64 {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(int64(f.Value))}
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -100,20 +172,79 @@ func (r *ReturnValue) Inspect() string {
 	return r.Value.Inspect()
 }
 
+// Break 和 Continue 像 ReturnValue 一样沿着语句块向外传递，直到所在的循环
+type Break struct{}
+
+func (*Break) Type() ObjectType { return BREAK_OBJ }
+func (*Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (*Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (*Continue) Inspect() string  { return "continue" }
+
 type Error struct {
 	Message string
+	Kind    string         // 错误的种类，throw 抛出的错误默认为 Error
+	Value   Object         // throw 抛出的原始值
+	Pos     token.Position // 产生错误的位置
+	Cause   *Error         // 在处理 Cause 的 catch 语句块中产生了这个错误
+	Trace   []Frame        // 错误向外传播时经过的函数调用，最内层的调用在前
+}
+
+// Frame 是调用栈中的一次函数调用
+type Frame struct {
+	Function string         // 函数名，匿名函数为空
+	Pos      token.Position // 调用发生的位置
 }
 
 func (*Error) Type() ObjectType {
 	return ERROR_OBJ
 }
 
+// Inspect 输出错误信息，之后是类似 traceback 的调用栈和引起错误的上一个错误
 func (e *Error) Inspect() string {
-	return "ERROR: " + e.Message
+	var out bytes.Buffer
+	out.WriteString("ERROR: ")
+	if e.Pos.IsValid() {
+		out.WriteString(e.Pos.String() + ": ")
+	}
+	if e.Kind != "" {
+		out.WriteString(e.Kind + ": ")
+	}
+	out.WriteString(e.Message)
+
+	if len(e.Trace) > 0 {
+		out.WriteString("\nTraceback (most recent call first):")
+		for i := 0; i < len(e.Trace); {
+			frame := e.Trace[i]
+			name := frame.Function
+			if name == "" {
+				name = "<anonymous>"
+			}
+			out.WriteString("\n  at " + name + " (" + frame.Pos.String() + ")")
+
+			// 递归调用产生的连续相同的调用只输出一次
+			n := 1
+			for i+n < len(e.Trace) && e.Trace[i+n] == frame {
+				n++
+			}
+			if n > 1 {
+				out.WriteString(fmt.Sprintf("\n  [previous frame repeated %d more times]", n-1))
+			}
+			i += n
+		}
+	}
+	if e.Cause != nil {
+		out.WriteString("\nCaused by: " + e.Cause.Inspect())
+	}
+	return out.String()
 }
 
 type Function struct {
-	Parameters []*ast.Identifer
+	Name       string           // 具名函数声明或者 let 绑定的函数名，匿名函数为空
+	Parameters []*ast.Parameter // 参数的默认值在调用时于函数的环境中求值
+	Rest       *ast.Identifer
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -124,14 +255,10 @@ func (*Function) Type() ObjectType {
 
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
 
 	out.WriteString("fn")
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ast.ParametersString(f.Parameters, f.Rest))
 	out.WriteString(") {\n")
 	out.WriteString(f.Body.String())
 	out.WriteString("\n}")
@@ -172,6 +299,7 @@ func (b *Buildin) Inspect() string {
 
 type Array struct {
 	Elements []Object
+	Frozen   bool // 被 freeze() 冻结后不能再修改
 }
 
 func (*Array) Type() ObjectType {
@@ -198,7 +326,9 @@ type HashPair struct {
 }
 
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey]HashPair
+	Frozen bool   // 被 freeze() 冻结后不能再修改
+	Err    *Error // catch 得到的哈希对应的错误，再次抛出时沿用原来的错误
 }
 
 func (h *Hash) Type() ObjectType {
@@ -261,3 +391,28 @@ func (m *Marco) Inspect() string {
 
 	return out.String()
 }
+
+// Module 是 import 加载的模块，只有导出的名字可以从模块外部访问。
+// 访问时从模块的环境中读取，所以模块内部对导出变量的修改对外部可见
+type Module struct {
+	Name    string          // 不带扩展名的文件名
+	Path    string          // 模块文件的绝对路径
+	Env     *Environment    // 模块顶层的环境
+	Exports map[string]bool // 导出的名字
+}
+
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
+}
+
+func (m *Module) Inspect() string {
+	return "module(" + m.Name + ")"
+}
+
+// Member 返回模块导出的名字对应的值，名字没有导出时ok为false
+func (m *Module) Member(name string) (Object, bool) {
+	if !m.Exports[name] {
+		return nil, false
+	}
+	return m.Env.Get(name)
+}
@@ -1,6 +1,9 @@
 package object
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "hello world"}
@@ -58,3 +61,92 @@ func TestBooleanHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestFloatHashKey(t *testing.T) {
+	pi1 := &Float{Value: 3.14}
+	pi2 := &Float{Value: 3.14}
+	diff := &Float{Value: 2.71}
+
+	if pi1.HashKey() != pi2.HashKey() {
+		t.Errorf("floats with same content have different hash keys")
+	}
+
+	if pi1.HashKey() == diff.HashKey() {
+		t.Errorf("floats with different content have same hash keys")
+	}
+
+	if (&Float{Value: 2}).HashKey() != (&Integer{Value: 2}).HashKey() {
+		t.Errorf("float and integer with same value have different hash keys")
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{3.5, "3.5"},
+		{2, "2.0"},
+		{-0.25, "-0.25"},
+		{1e21, "1e+21"},
+		{1.5e-7, "1.5e-07"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("Inspect wrong. want=%q, got=%q", tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestBigIntHashKey(t *testing.T) {
+	big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	big2, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	negative := new(big.Int).Neg(big1)
+
+	if (&BigInt{Value: big1}).HashKey() != (&BigInt{Value: big2}).HashKey() {
+		t.Errorf("big integers with same content have different hash keys")
+	}
+
+	if (&BigInt{Value: big1}).HashKey() == (&BigInt{Value: negative}).HashKey() {
+		t.Errorf("big integers with different content have same hash keys")
+	}
+
+	if (&BigInt{Value: big.NewInt(42)}).HashKey() != (&Integer{Value: 42}).HashKey() {
+		t.Errorf("big integer and integer with same value have different hash keys")
+	}
+}
+
+func TestEnvironmentConst(t *testing.T) {
+	outer := NewEnvironment()
+	outer.SetConst("a", &Integer{Value: 1})
+	outer.Set("b", &Integer{Value: 2})
+	inner := NewEnclosedEnvironment(outer)
+
+	if !inner.IsConst("a") {
+		t.Errorf("a should be const in enclosed environment")
+	}
+	if inner.IsConst("b") || inner.IsConst("c") {
+		t.Errorf("b and c should not be const")
+	}
+
+	if !inner.Assign("b", &Integer{Value: 3}) {
+		t.Fatalf("Assign(b) returned false")
+	}
+	if b, _ := outer.Get("b"); b.(*Integer).Value != 3 {
+		t.Errorf("Assign did not update outer binding. got=%s", b.Inspect())
+	}
+	if inner.Assign("c", &Integer{Value: 4}) {
+		t.Errorf("Assign(c) should fail for undefined name")
+	}
+
+	if inner.IsLocalConst("a") || !outer.IsLocalConst("a") {
+		t.Errorf("IsLocalConst should only check the current scope")
+	}
+
+	inner.Set("a", &Integer{Value: 5})
+	if inner.IsConst("a") {
+		t.Errorf("shadowing binding of a should not be const")
+	}
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fengshux/monkey/token"
+)
+
+// ErrorCode 标识语法错误的种类
+type ErrorCode string
+
+const (
+	ErrLexical             ErrorCode = "lexical"              // 词法错误，如未结束的字符串
+	ErrUnexpectedToken     ErrorCode = "unexpected_token"     // 下一个词法单元不是期望的类型
+	ErrNoPrefixParseFn     ErrorCode = "no_prefix_parse_fn"   // 词法单元不能作为表达式的开始
+	ErrInvalidLiteral      ErrorCode = "invalid_literal"      // 数字字面量无法解析或溢出
+	ErrOutsideLoop         ErrorCode = "outside_loop"         // break 或 continue 不在循环中
+	ErrInvalidAssignment   ErrorCode = "invalid_assignment"   // 赋值表达式的左侧不能被赋值
+	ErrInvalidPattern      ErrorCode = "invalid_pattern"      // 解构模式不合法
+	WarnNonExhaustiveMatch ErrorCode = "non_exhaustive_match" // 警告：对布尔值或 null 的 match 没有覆盖所有情况
+	ErrInvalidParameter    ErrorCode = "invalid_parameter"    // 形参列表不合法
+	ErrInvalidArgument     ErrorCode = "invalid_argument"     // 实参列表不合法，如重复的命名实参
+	ErrInvalidExport       ErrorCode = "invalid_export"       // export 不在顶层或者导出的不是声明
+)
+
+// ParseError 是一个带有源代码位置的语法错误
+type ParseError struct {
+	Pos      token.Position    `json:"pos"`
+	Code     ErrorCode         `json:"code"`
+	Msg      string            `json:"message"`
+	Expected []token.TokenType `json:"expected,omitempty"` // 期望的词法单元类型
+	Actual   token.Token       `json:"actual"`             // 实际遇到的词法单元，词法错误时为空
+}
+
+// Error 返回 pos: msg 格式的错误信息
+func (e *ParseError) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// ErrorList 是语法错误的列表，实现了 error 接口
+type ErrorList []*ParseError
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l ErrorList) Less(i, j int) bool {
+	e, f := l[i].Pos, l[j].Pos
+	if e.Filename != f.Filename {
+		return e.Filename < f.Filename
+	}
+	return e.Offset < f.Offset
+}
+
+// Sort 按照错误在源代码中的位置排序
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+// Filter 返回满足 keep 的错误组成的新列表
+func (l ErrorList) Filter(keep func(*ParseError) bool) ErrorList {
+	var res ErrorList
+	for _, e := range l {
+		if keep(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Error 返回第一个错误的信息，有多个错误时附带剩余错误的数量
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err 在列表为空时返回nil，否则返回列表本身
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/fengshux/monkey/ast"
 	"github.com/fengshux/monkey/lexer"
@@ -12,13 +14,22 @@ import (
 const (
 	_ int = iota
 	LOWEST
-	EQUALS     // ==
-	LESSGEAGER // < or >
-	SUM        // +
-	PRODUCT    // *
-	PREFIX     // -x or !x
-	CALL       // myFunc()
+	ASSIGN      // = 右结合
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
+	EQUALS      // ==
+	LESSGEAGER  // < or > or <= or >=
+	BIT_OR      // |
+	BIT_XOR     // ^
+	BIT_AND     // &
+	SHIFT       // << or >>
+	SUM         // +
+	PRODUCT     // * / %
+	PREFIX      // -x or !x or ~x
+	POWER       // ** 右结合，比前缀运算符优先级高，-2 ** 2 == -(2 ** 2)
+	CALL        // myFunc()
 	INDEX
+	MEMBER // obj.name
 )
 
 type (
@@ -28,7 +39,14 @@ type (
 
 type Parser struct {
 	l              *lexer.Lexer
-	errors         []string
+	errors         ErrorList
+	warnings       ErrorList
+	panicking      bool      // 当前语句已经出错，同步之前不再记录新的错误
+	nesting        int       // curToken 所在的括号层数，包括 ()、[]、{} 和字符串插值
+	loopDepth      int       // 当前函数内包围当前位置的循环层数
+	blockDepth     int       // 包围当前位置的语句块层数，为0时位于顶层
+	lexErrors      ErrorList // peekToken 的词法错误，peekToken 成为 curToken 时才记录
+	comments       []*ast.Comment
 	curToken       token.Token
 	peekToken      token.Token
 	prefixParseFns map[token.TokenType]prefixParseFn
@@ -38,34 +56,49 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:              l,
-		errors:         []string{},
 		prefixParseFns: make(map[token.TokenType]prefixParseFn),
 		infixParsefns:  make(map[token.TokenType]infixParsefn),
 	}
 
+	// 词法错误同样作为语法错误报告
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		p.lexErrors = append(p.lexErrors, &ParseError{Pos: pos, Code: ErrLexical, Msg: msg})
+	})
+
 	p.registerPrefix(token.IDENT, p.parseIdentifer)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERP_HEAD, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parserHashLiteral)
 	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.IMPORT, p.parseImportExpression)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	// 以下这些符号都用parseInfixExpression
 	for _, v := range []token.TokenType{token.PLUS, token.MINUS,
 		token.SLASH, token.ASTERISK, token.EQ, token.NOT_EQ,
-		token.LT, token.GT,
+		token.LT, token.GT, token.LT_EQ, token.GT_EQ, token.AND, token.OR,
+		token.PERCENT, token.POWER,
+		token.BIT_AND, token.BIT_OR, token.BIT_XOR, token.SHL, token.SHR,
 	} {
 		p.registerInfix(v, p.parseInfixExpression)
 	}
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
 	// 读取两个词法单元，以设置curToken和peekToken
 	p.nextToken()
 	p.nextToken()
@@ -80,23 +113,70 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParsefn) {
 	p.infixParsefns[tokenType] = fn
 }
 
-func (p *Parser) Errors() []string {
+// Errors 返回语法分析过程中遇到的所有错误，按发现的顺序排列
+func (p *Parser) Errors() ErrorList {
 	return p.errors
 }
 
+// Warnings 返回语法分析过程中发现的警告，警告不影响程序的执行
+func (p *Parser) Warnings() ErrorList {
+	return p.warnings
+}
+
+// error 记录语法错误。一条语句中只记录第一个错误，之后的错误大多是它引起的，
+// 在 synchronize 跳过这条语句之前都会被丢弃
+func (p *Parser) error(err *ParseError) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+	p.errors = append(p.errors, err)
+}
+
+// tokenError 记录一个由词法单元tok引起的语法错误
+func (p *Parser) tokenError(tok token.Token, code ErrorCode, format string, args ...interface{}) {
+	p.error(&ParseError{Pos: tok.Pos, Code: code, Msg: fmt.Sprintf(format, args...), Actual: tok})
+}
+
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.error(&ParseError{
+		Pos:      p.peekToken.Pos,
+		Code:     ErrUnexpectedToken,
+		Msg:      fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type),
+		Expected: []token.TokenType{t},
+		Actual:   p.peekToken,
+	})
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.tokenError(p.curToken, ErrNoPrefixParseFn, "no prefix parse function for %s found", t)
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
+
+	// 词法错误属于出错的词法单元所在的语句，不受 panicking 的影响，总是会被记录；
+	// 之后由这个词法单元引起的语法错误则被丢弃
+	if len(p.lexErrors) > 0 {
+		p.errors = append(p.errors, p.lexErrors...)
+		p.lexErrors = nil
+		p.panicking = true
+	}
+
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case token.LPAREN, token.LBRACKET, token.LBRACE, token.INTERP_HEAD:
+		p.nesting++
+	case token.RPAREN, token.RBRACKET, token.RBRACE, token.INTERP_TAIL:
+		p.nesting--
+	}
+
+	// 注释不参与语法分析，收集起来留给格式化等工具使用
+	for p.peekToken.Type == token.COMMENT {
+		p.comments = append(p.comments, &ast.Comment{Token: p.peekToken})
+		p.peekToken = p.l.NextToken()
+	}
 }
 
 func (p *Parser) curPrecedence() int {
@@ -125,18 +205,93 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.nextToken()
 	}
 
+	program.Comments = p.comments
 	return program
 }
 
+// ParseStatement 解析一条语句。语句中出现语法错误时跳到下一个语句边界继续解析，
+// 无法构造的语句用 ast.BadStatement 代替，这样一次能报告所有互不相关的错误
 func (p *Parser) ParseStatement() ast.Statement {
+	start := p.curToken
+	nesting := p.nesting
 
+	var stmt ast.Statement
 	switch p.curToken.Type {
-	case token.LET:
-		return p.parseLetStatement()
+	case token.LET, token.CONST:
+		if s := p.parseLetStatement(); s != nil {
+			stmt = s
+		}
 	case token.RETURN:
-		return p.parseReturnStatement()
+		stmt = p.parseReturnStatement()
+	case token.WHILE:
+		if s := p.parseWhileStatement(); s != nil {
+			stmt = s
+		}
+	case token.FOR:
+		if s := p.parseForInStatement(); s != nil {
+			stmt = s
+		}
+	case token.BREAK, token.CONTINUE:
+		stmt = p.parseBranchStatement()
+	case token.TRY:
+		if s := p.parseTryStatement(); s != nil {
+			stmt = s
+		}
+	case token.THROW:
+		stmt = p.parseThrowStatement()
+	case token.EXPORT:
+		if s := p.parseExportStatement(); s != nil {
+			stmt = s
+		}
+	case token.FUNCTION:
+		if !p.peekTokenIs(token.IDENT) {
+			stmt = p.parseExpressionStatement()
+		} else if s := p.parseFunctionStatement(); s != nil {
+			stmt = s
+		}
 	default:
-		return p.parseExpressionStatement()
+		stmt = p.parseExpressionStatement()
+	}
+
+	if p.panicking {
+		p.synchronize(nesting)
+	}
+	if stmt == nil {
+		stmt = &ast.BadStatement{From: start.Pos, To: p.curToken.End()}
+	}
+	return stmt
+}
+
+// synchronize 跳过词法单元直到当前语句结束：当前词法单元是 ; 或者下一个词法单元
+// 可以开始新的语句或结束语句块。出错之后成对的括号会被整个跳过，其中的 ; 和 } 不会结束同步；
+// 只有回到语句开始时的括号层数 nesting，下一个 } 才会被当作语句块的结束
+func (p *Parser) synchronize(nesting int) {
+	defer func() { p.panicking = false }()
+
+	depth := 0 // 同步过程中遇到的还没有闭合的括号
+	for !p.curTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		}
+		if depth == 0 {
+			if p.curTokenIs(token.SEMICOLON) {
+				return
+			}
+			switch p.peekToken.Type {
+			case token.LET, token.CONST, token.RETURN, token.WHILE, token.FOR, token.TRY, token.THROW, token.EOF:
+				return
+			case token.RBRACE:
+				if p.nesting <= nesting {
+					return
+				}
+			}
+		}
+		p.nextToken()
 	}
 }
 
@@ -160,12 +315,28 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
-	stmt.Name = &ast.Identifer{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		if stmt.Pattern = p.parsePattern(); stmt.Pattern == nil {
+			return nil
+		}
+		// 同一个模式中的名字不能重复
+		seen := make(map[string]bool)
+		for _, name := range ast.PatternNames(stmt.Pattern) {
+			if seen[name.Value] {
+				p.tokenError(name.Token, ErrInvalidPattern, "duplicate name in pattern: %s", name.Value)
+				break
+			}
+			seen[name.Value] = true
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifer{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
 	}
 
 	if !p.expectPeek(token.ASSIGN) {
@@ -183,6 +354,97 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+// parsePattern 解析 let 和函数参数中的绑定目标：标识符、数组模式 [a, ...rest] 或者哈希模式 {name, age: years}
+func (p *Parser) parsePattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern(p.parsePattern)
+	case token.LBRACE:
+		return p.parseHashPattern(p.parsePattern)
+	default:
+		p.tokenError(p.curToken, ErrInvalidPattern, "expected identifier or destructuring pattern, got %s instead", p.curToken.Type)
+		return nil
+	}
+}
+
+// parseArrayPattern 解析数组模式，元素由elem解析
+func (p *Parser) parseArrayPattern(elem func() ast.Expression) ast.Expression {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+
+		el := elem()
+		if el == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, el)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	pattern.Rbracket = p.curToken
+	return pattern
+}
+
+// parseHashPattern 解析哈希模式，键对应的模式由elem解析
+func (p *Parser) parseHashPattern(elem func() ast.Expression) ast.Expression {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		var key ast.Expression
+		switch p.curToken.Type {
+		case token.IDENT:
+			key = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+		case token.STRING:
+			key = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+		default:
+			p.tokenError(p.curToken, ErrInvalidPattern, "expected hash pattern key, got %s instead", p.curToken.Type)
+			return nil
+		}
+
+		value := key
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			if value = elem(); value == nil {
+				return nil
+			}
+		} else if _, ok := key.(*ast.Identifer); !ok {
+			p.peekError(token.COLON)
+			return nil
+		}
+		pattern.Keys = append(pattern.Keys, key)
+		pattern.Values = append(pattern.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	pattern.Rbrace = p.curToken
+	return pattern
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatment {
 	stmt := &ast.ReturnStatment{Token: p.curToken}
 
@@ -195,6 +457,176 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatment {
 	return stmt
 }
 
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForInStatement() *ast.ForInStatement {
+	stmt := &ast.ForInStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parseTryStatement 解析 try { } catch (e) { } finally { }，catch 和 finally 可以省略其中一个
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Param = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		p.tokenError(p.peekToken, ErrUnexpectedToken, "expected catch or finally after try block, got %s instead", p.peekToken.Type)
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parseExportStatement 解析 export let、export const 和 export fn name() { }，只能出现在顶层
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	if p.blockDepth > 0 {
+		p.tokenError(p.curToken, ErrInvalidExport, "export is only allowed at the top level")
+		return nil
+	}
+
+	p.nextToken()
+	switch {
+	case p.curTokenIs(token.LET), p.curTokenIs(token.CONST):
+		if s := p.parseLetStatement(); s != nil {
+			stmt.Statement = s
+		}
+	case p.curTokenIs(token.FUNCTION) && p.peekTokenIs(token.IDENT):
+		if s := p.parseFunctionStatement(); s != nil {
+			stmt.Statement = s
+		}
+	default:
+		p.tokenError(p.curToken, ErrInvalidExport, "expected let, const or function declaration after export, got %s instead", p.curToken.Type)
+	}
+
+	if stmt.Statement == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseImportExpression 解析 import "path"，路径必须是字符串字面量
+func (p *Parser) parseImportExpression() ast.Expression {
+	exp := &ast.ImportExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	exp.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return p.parseBlockStatement()
+}
+
+func (p *Parser) parseBranchStatement() *ast.BranchStatement {
+	stmt := &ast.BranchStatement{Token: p.curToken}
+	if p.loopDepth == 0 {
+		p.tokenError(p.curToken, ErrOutsideLoop, "%s is not in a loop", p.curToken.Literal)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
@@ -206,12 +638,16 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
+	start := p.curToken
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		return p.badExpression(start)
 	}
 	leftExp := prefix()
+	if leftExp == nil {
+		return p.badExpression(start)
+	}
 
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParsefns[p.peekToken.Type]
@@ -221,11 +657,19 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.nextToken()
 
 		leftExp = infix(leftExp)
+		if leftExp == nil {
+			return p.badExpression(start)
+		}
 	}
 
 	return leftExp
 }
 
+// badExpression 返回从start到当前词法单元的占位表达式
+func (p *Parser) badExpression(start token.Token) ast.Expression {
+	return &ast.BadExpression{From: start.Pos, To: p.curToken.End()}
+}
+
 func (p *Parser) parseIdentifer() ast.Expression {
 	return &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
 }
@@ -234,9 +678,30 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	li := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.tokenError(p.curToken, ErrInvalidLiteral, "integer literal %s overflows int64", p.curToken.Literal)
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("cloud not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.tokenError(p.curToken, ErrInvalidLiteral, "cloud not parse %q as integer", p.curToken.Literal)
+		return nil
+	}
+
+	li.Value = value
+	return li
+}
+
+// parseIllegal 处理非法词法单元，具体的错误已经由词法分析器报告
+func (p *Parser) parseIllegal() ast.Expression {
+	return nil
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	li := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.tokenError(p.curToken, ErrInvalidLiteral, "cloud not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
@@ -263,11 +728,177 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	percedence := p.curPrecedence()
+	// 右结合的运算符以较低的优先级解析右侧，使 a ** b ** c 解析为 a ** (b ** c)
+	if rightAssociative[p.curToken.Type] {
+		percedence--
+	}
 	p.nextToken()
 	expresstion.Right = p.parseExpression(percedence)
 	return expresstion
 }
 
+// parseAssignExpression 解析赋值表达式，左侧只能是标识符或者索引表达式
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{Token: p.curToken, Target: target}
+
+	switch target.(type) {
+	case *ast.Identifer, *ast.IndexExpression, *ast.MemberExpression:
+	default:
+		p.tokenError(p.curToken, ErrInvalidAssignment, "cannot assign to %s", target.String())
+		return nil
+	}
+
+	p.nextToken()
+	exp.Value = p.parseExpression(ASSIGN - 1)
+	return exp
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := &ast.MatchArm{Pattern: p.parseMatchPattern()}
+		if arm.Pattern == nil {
+			return nil
+		}
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.parseExpression(LOWEST)
+		}
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		p.nextToken()
+		arm.Body = p.parseExpression(LOWEST)
+		exp.Arms = append(exp.Arms, arm)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	exp.Rbrace = p.curToken
+
+	p.checkExhaustive(exp)
+	return exp
+}
+
+// parseMatchPattern 解析 match 分支的模式：字面量、通配符 _、绑定、数组和哈希模式，
+// 模式后面可以跟类型测试 : TYPE
+func (p *Parser) parseMatchPattern() ast.Expression {
+	var pattern ast.Expression
+	switch p.curToken.Type {
+	case token.IDENT:
+		pattern = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE, token.NULL:
+		pattern = p.prefixParseFns[p.curToken.Type]()
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			p.peekError(token.INT)
+			return nil
+		}
+		prefix := &ast.PrefixExpression{Token: p.curToken, Operator: p.curToken.Literal}
+		p.nextToken()
+		if prefix.Right = p.prefixParseFns[p.curToken.Type](); prefix.Right == nil {
+			return nil
+		}
+		pattern = prefix
+	case token.LBRACKET:
+		pattern = p.parseArrayPattern(p.parseMatchPattern)
+	case token.LBRACE:
+		pattern = p.parseHashPattern(p.parseMatchPattern)
+	default:
+		p.tokenError(p.curToken, ErrInvalidPattern, "expected match pattern, got %s instead", p.curToken.Type)
+		return nil
+	}
+	if pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		pattern = &ast.TypePattern{
+			Pattern: pattern,
+			Type:    &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal},
+		}
+	}
+	return pattern
+}
+
+// checkExhaustive 检查对布尔值或 null 的 match 是否覆盖了所有情况，没有时记录一个警告。
+// 带有 guard 的分支不算覆盖
+func (p *Parser) checkExhaustive(exp *ast.MatchExpression) {
+	covered := map[string]bool{}
+	for _, arm := range exp.Arms {
+		if arm.Guard != nil {
+			continue
+		}
+		switch pattern := arm.Pattern.(type) {
+		case *ast.Identifer:
+			return
+		case *ast.Boolean, *ast.NullLiteral:
+			covered[pattern.String()] = true
+		case *ast.TypePattern:
+			if _, ok := pattern.Pattern.(*ast.Identifer); !ok {
+				continue
+			}
+			switch pattern.Type.Value {
+			case "BOOLEAN":
+				covered["true"], covered["false"] = true, true
+			case "NULL":
+				covered["null"] = true
+			}
+		}
+	}
+
+	var missing []string
+	switch {
+	case covered["true"] || covered["false"]:
+		for _, v := range []string{"true", "false"} {
+			if !covered[v] {
+				missing = append(missing, v)
+			}
+		}
+	case covered["null"]:
+		missing = append(missing, "non-null values")
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	p.warnings = append(p.warnings, &ParseError{
+		Pos:    exp.Token.Pos,
+		Code:   WarnNonExhaustiveMatch,
+		Msg:    "match is not exhaustive: missing " + strings.Join(missing, ", "),
+		Actual: exp.Token,
+	})
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -320,6 +951,9 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
@@ -329,55 +963,171 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	block.Rbrace = p.curToken
 	return block
 }
 
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	function := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.parseFunction(function) {
+		return nil
+	}
+	return function
+}
 
-	if !p.expectPeek(token.LPAREN) {
+// parseFunctionStatement 解析具名函数声明 fn name(params) { body }
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	stmt := &ast.FunctionStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Name = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+
+	stmt.Function = &ast.FunctionLiteral{Token: stmt.Token}
+	if !p.parseFunction(stmt.Function) {
 		return nil
 	}
 
-	function.Parameters = p.parseFunctionParameters()
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
 
+// parseFunction 解析函数的参数列表和函数体，成功时返回true
+func (p *Parser) parseFunction(function *ast.FunctionLiteral) bool {
+	if !p.expectPeek(token.LPAREN) {
+		return false
+	}
+	if !p.parseFunctionParameters(function) {
+		return false
+	}
 	if !p.expectPeek(token.LBRACE) {
-		return nil
+		return false
 	}
-	function.Body = p.parseBlockStatement()
-	return function
+	function.Body = p.parseFunctionBody()
+	return true
+}
+
+// parseFunctionBody 解析函数体，函数体中的 break 和 continue 不能作用于函数外的循环
+func (p *Parser) parseFunctionBody() *ast.BlockStatement {
+	depth := p.loopDepth
+	p.loopDepth = 0
+	defer func() { p.loopDepth = depth }()
+	return p.parseBlockStatement()
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifer {
-	identifers := []*ast.Identifer{}
+// parseFunctionParameters 解析形参列表 (x, y = 2, ...rest)，剩余参数只能放在最后。
+// 形参（包括解构模式和剩余参数中）的名字不能重复
+func (p *Parser) parseFunctionParameters(function *ast.FunctionLiteral) bool {
+	function.Parameters = []*ast.Parameter{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifers
+		return true
 	}
-	p.nextToken()
-
-	ident := &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
-	identifers = append(identifers, ident)
 
-	for p.peekTokenIs(token.COMMA) {
+	seen := make(map[string]bool)
+	for {
 		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			function.Rest = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+			p.checkDuplicateParameter(function.Rest, seen)
+			break
+		}
+
+		param := &ast.Parameter{}
+		switch p.curToken.Type {
+		case token.IDENT:
+			param.Name = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+			p.checkDuplicateParameter(param.Name, seen)
+		case token.LBRACKET, token.LBRACE:
+			if param.Pattern = p.parsePattern(); param.Pattern == nil {
+				return false
+			}
+			for _, name := range ast.PatternNames(param.Pattern) {
+				p.checkDuplicateParameter(name, seen)
+			}
+		default:
+			p.tokenError(p.curToken, ErrInvalidParameter, "expected parameter name, got %s instead", p.curToken.Type)
+			return false
+		}
+
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			param.Default = p.parseExpression(ASSIGN)
+		}
+		function.Parameters = append(function.Parameters, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		ident := &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
-		identifers = append(identifers, ident)
 	}
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+
+	return p.expectPeek(token.RPAREN)
+}
+
+// checkDuplicateParameter 检查形参的名字是否已经出现过，seen 记录已经出现的名字
+func (p *Parser) checkDuplicateParameter(name *ast.Identifer, seen map[string]bool) {
+	if seen[name.Value] {
+		p.tokenError(name.Token, ErrInvalidParameter, "duplicate parameter name: %s", name.Value)
+		return
 	}
-	return identifers
+	seen[name.Value] = true
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if !p.parseCallArguments(exp) {
+		return nil
+	}
+	exp.Rparen = p.curToken
 	return exp
 }
 
+// parseCallArguments 解析调用的实参，命名实参 name: value 只能出现在位置实参之后
+func (p *Parser) parseCallArguments(exp *ast.CallExpression) bool {
+	exp.Arguments = []ast.Expression{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return true
+	}
+
+	seen := map[string]bool{}
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			name := &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
+			if seen[name.Value] {
+				p.tokenError(p.curToken, ErrInvalidArgument, "duplicate keyword argument %s", name.Value)
+			}
+			seen[name.Value] = true
+
+			p.nextToken()
+			p.nextToken()
+			exp.Keywords = append(exp.Keywords,
+				&ast.KeywordArgument{Name: name, Value: p.parseExpression(LOWEST)})
+		} else {
+			if len(exp.Keywords) > 0 {
+				p.tokenError(p.curToken, ErrInvalidArgument, "positional argument follows keyword argument")
+			}
+			exp.Arguments = append(exp.Arguments, p.parseExpression(LOWEST))
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	return p.expectPeek(token.RPAREN)
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -404,9 +1154,34 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken, Strings: []string{p.curToken.Literal}}
+
+	for {
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+		if !p.peekTokenIs(token.INTERP_MID) {
+			break
+		}
+		p.nextToken()
+		str.Strings = append(str.Strings, p.curToken.Literal)
+	}
+
+	if !p.expectPeek(token.INTERP_TAIL) {
+		return nil
+	}
+	str.Strings = append(str.Strings, p.curToken.Literal)
+	str.Tail = p.curToken
+	return str
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	arr := &ast.ArrayLiteral{Token: p.curToken}
 	arr.Elements = p.parseExpressionList(token.RBRACKET)
+	if arr.Elements == nil {
+		return nil
+	}
+	arr.Rbracket = p.curToken
 	return arr
 }
 
@@ -418,6 +1193,17 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	exp.Rbracket = p.curToken
+	return exp
+}
+
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifer{Token: p.curToken, Value: p.curToken.Literal}
 	return exp
 }
 
@@ -445,6 +1231,7 @@ func (p *Parser) parserHashLiteral() ast.Expression {
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
+	hash.Rbrace = p.curToken
 	return hash
 }
 
@@ -455,11 +1242,27 @@ func (p *Parser) parseMacroLiteral() ast.Expression {
 		return nil
 	}
 
-	mac.Parameters = p.parseFunctionParameters()
+	// 宏的参数是未求值的语法树，不支持默认值、解构和剩余参数
+	params := &ast.FunctionLiteral{}
+	if !p.parseFunctionParameters(params) {
+		return nil
+	}
+	invalid := params.Rest != nil
+	mac.Parameters = []*ast.Identifer{}
+	for _, param := range params.Parameters {
+		if param.Pattern != nil || param.Default != nil {
+			invalid = true
+			continue
+		}
+		mac.Parameters = append(mac.Parameters, param.Name)
+	}
+	if invalid {
+		p.tokenError(mac.Token, ErrInvalidParameter, "macro parameters cannot have default values, patterns or rest parameter")
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
-	mac.Body = p.parseBlockStatement()
+	mac.Body = p.parseFunctionBody()
 	return mac
 }
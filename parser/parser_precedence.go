@@ -5,14 +5,32 @@ import (
 )
 
 var precedences = map[token.TokenType]int{
+	token.ASSIGN:   ASSIGN,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGEAGER,
 	token.GT:       LESSGEAGER,
+	token.LT_EQ:    LESSGEAGER,
+	token.GT_EQ:    LESSGEAGER,
+	token.AND:      LOGICAL_AND,
+	token.OR:       LOGICAL_OR,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.POWER:    POWER,
+	token.BIT_OR:   BIT_OR,
+	token.BIT_XOR:  BIT_XOR,
+	token.BIT_AND:  BIT_AND,
+	token.SHL:      SHIFT,
+	token.SHR:      SHIFT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      MEMBER,
+}
+
+// rightAssociative 记录右结合的中缀运算符
+var rightAssociative = map[token.TokenType]bool{
+	token.POWER: true,
 }
@@ -1,12 +1,14 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"testing"
 
 	"github.com/fengshux/monkey/ast"
 	"github.com/fengshux/monkey/lexer"
+	"github.com/fengshux/monkey/token"
 )
 
 func checkParserErrors(t *testing.T, p *Parser) {
@@ -473,6 +475,58 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"a ** b ** c",
+			"(a ** (b ** c))",
+		},
+		{
+			"-a ** b",
+			"(-(a ** b))",
+		},
+		{
+			"a * b ** -c",
+			"(a * (b ** (-c)))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b << c + d",
+			"(a & (b << (c + d)))",
+		},
+		{
+			"a >> 1 << 2",
+			"((a >> 1) << 2)",
+		},
+		{
+			"a & 1 == 0",
+			"((a & 1) == 0)",
+		},
+		{
+			"~a & b < c",
+			"(((~a) & b) < c)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"!a && b == c || d < e + 1",
+			"(((!a) && (b == c)) || (d < (e + 1)))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -608,8 +662,8 @@ func TestFunctionLiteralParsing(t *testing.T) {
 		t.Fatalf("function parameters wrong, want 2 got=%d", len(function.Parameters))
 	}
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testLiteralExpression(t, function.Parameters[0].Name, "x")
+	testLiteralExpression(t, function.Parameters[1].Name, "y")
 
 	if len(function.Body.Statements) != 1 {
 		t.Fatalf("function body.Statements has not 1 statements, got=%d", len(function.Body.Statements))
@@ -647,7 +701,7 @@ func TestFunctionParameterParsing(t *testing.T) {
 		}
 
 		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+			testLiteralExpression(t, function.Parameters[i].Name, ident)
 		}
 	}
 }
@@ -981,3 +1035,955 @@ func TestMacroLiteralParsing(t *testing.T) {
 
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
+
+func TestParserErrorPosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x 5;", "1:7: expected next token to be =, got INT instead"},
+		{"let x = 1;\nadd(1, 2;", "2:9: expected next token to be ), got ; instead"},
+		{"\n\n  ;", "3:3: no prefix parse function for ; found"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestParseErrorDetails(t *testing.T) {
+	l := lexer.NewFile("a.mk", "let x 5;\nlet y = \"abc")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 parser errors. got=%v", errors)
+	}
+
+	e := errors[0]
+	if e.Code != ErrUnexpectedToken {
+		t.Errorf("e.Code wrong. expected=%q, got=%q", ErrUnexpectedToken, e.Code)
+	}
+	if len(e.Expected) != 1 || e.Expected[0] != token.ASSIGN {
+		t.Errorf("e.Expected wrong. got=%v", e.Expected)
+	}
+	if e.Actual.Type != token.INT || e.Actual.Literal != "5" {
+		t.Errorf("e.Actual wrong. got=%+v", e.Actual)
+	}
+	if e.Pos.String() != "a.mk:1:7" {
+		t.Errorf("e.Pos wrong. got=%q", e.Pos)
+	}
+	if errors[1].Code != ErrLexical {
+		t.Errorf("errors[1].Code wrong. expected=%q, got=%q", ErrLexical, errors[1].Code)
+	}
+
+	expected := "a.mk:1:7: expected next token to be =, got INT instead (and 1 more errors)"
+	if errors.Error() != expected {
+		t.Errorf("errors.Error() wrong. expected=%q, got=%q", expected, errors.Error())
+	}
+
+	lexical := errors.Filter(func(e *ParseError) bool { return e.Code == ErrLexical })
+	if len(lexical) != 1 || lexical[0] != errors[1] {
+		t.Errorf("errors.Filter wrong. got=%v", lexical)
+	}
+
+	data, err := json.Marshal(errors[:1])
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	expectedJSON := `[{"pos":{"filename":"a.mk","offset":6,"line":1,"column":7},"code":"unexpected_token",` +
+		`"message":"expected next token to be =, got INT instead","expected":["="],` +
+		`"actual":{"type":"INT","literal":"5","pos":{"filename":"a.mk","offset":6,"line":1,"column":7},` +
+		`"end":{"filename":"a.mk","offset":7,"line":1,"column":8}}}]`
+	if string(data) != expectedJSON {
+		t.Errorf("json wrong.\nexpected=%s\ngot=%s", expectedJSON, data)
+	}
+
+	if (ErrorList{}).Err() != nil {
+		t.Errorf("empty ErrorList.Err() should be nil")
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	input := `let x 5;
+let y = 10;
+let = 3;
+add(1, 2;
+let f = fn() { let z ; z };
+y + ;
+return y;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	expectedErrors := []string{
+		"1:7: expected next token to be =, got INT instead",
+		"3:5: expected next token to be IDENT, got = instead",
+		"4:9: expected next token to be ), got ; instead",
+		"5:22: expected next token to be =, got ; instead",
+		"6:5: no prefix parse function for ; found",
+	}
+	errors := p.Errors()
+	if len(errors) != len(expectedErrors) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%v)",
+			len(expectedErrors), len(errors), errors)
+	}
+	for i, msg := range expectedErrors {
+		if errors[i].Error() != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i].Error())
+		}
+	}
+
+	if len(program.Statements) != 7 {
+		t.Fatalf("program.Statements does not contain 7 statements. got=%d", len(program.Statements))
+	}
+
+	bad, ok := program.Statements[0].(*ast.BadStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.BadStatement. got=%T", program.Statements[0])
+	}
+	if bad.Pos().String() != "1:1" || bad.End().String() != "1:9" {
+		t.Errorf("bad statement range wrong. got=%s-%s", bad.Pos(), bad.End())
+	}
+	testLetStatement(t, program.Statements[1], "y")
+	if _, ok := program.Statements[2].(*ast.BadStatement); !ok {
+		t.Errorf("program.Statements[2] is not ast.BadStatement. got=%T", program.Statements[2])
+	}
+
+	call := program.Statements[3].(*ast.ExpressionStatement)
+	if _, ok := call.Expression.(*ast.BadExpression); !ok {
+		t.Errorf("call expression is not ast.BadExpression. got=%T", call.Expression)
+	}
+
+	let := program.Statements[4].(*ast.LetStatement)
+	body := let.Value.(*ast.FunctionLiteral).Body
+	if len(body.Statements) != 2 {
+		t.Fatalf("function body does not contain 2 statements. got=%d", len(body.Statements))
+	}
+	if _, ok := body.Statements[0].(*ast.BadStatement); !ok {
+		t.Errorf("body.Statements[0] is not ast.BadStatement. got=%T", body.Statements[0])
+	}
+	testIdentifer(t, body.Statements[1].(*ast.ExpressionStatement).Expression, "z")
+
+	if _, ok := program.Statements[6].(*ast.ReturnStatment); !ok {
+		t.Errorf("program.Statements[6] is not ast.ReturnStatment. got=%T", program.Statements[6])
+	}
+
+	// 每条出错的语句只报告第一个错误，同步时跳过语句中成对的括号
+	tests := []struct {
+		input    string
+		errors   []string
+		expected string
+	}{
+		{"let f = fn(x { x }; let g = 1;",
+			[]string{"1:14: expected next token to be ), got { instead"},
+			"let f = ;let g = 1;"},
+		{"if (x > ) { let a = 1; } let b = ) ;",
+			[]string{"1:9: no prefix parse function for ) found", "1:34: no prefix parse function for ) found"},
+			"let b = ;"},
+		{`let h = {9223372036854775808 - 1: "a"}`,
+			[]string{"1:10: integer literal 9223372036854775808 overflows int64"},
+			"let h = ;"},
+		{`"x ${1 + } y"`,
+			[]string{"1:10: no prefix parse function for INTERP_TAIL found"},
+			""},
+		{"let a = ;\n\"unterminated\nlet b = 2;",
+			[]string{"1:9: no prefix parse function for ; found", "2:1: string literal not terminated"},
+			"let a = ;let b = 2;"},
+		{"\xfflet b = 2;",
+			[]string{"1:1: invalid UTF-8 encoding"},
+			"let b = 2;"},
+		{"let a = 1 + @; let b = 2;",
+			[]string{"1:13: illegal character '@'"},
+			"let a = (1 + );let b = 2;"},
+		{"let f = fn() { let a = (1 + ; let b = 2 }; f",
+			[]string{"1:29: no prefix parse function for ; found"},
+			"let f = fn()let a = ;let b = 2;;f"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.errors) {
+			t.Errorf("wrong number of errors for %q. expected=%d, got=%d (%v)",
+				tt.input, len(tt.errors), len(errors), errors)
+			continue
+		}
+		for i, msg := range tt.errors {
+			if errors[i].Error() != msg {
+				t.Errorf("errors[%d] wrong for %q. expected=%q, got=%q", i, tt.input, msg, errors[i].Error())
+			}
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program wrong for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x; }", "while ((x < 10)) x"},
+		{"for (item in items) { break; continue }", "for (item in items) break;continue;"},
+		{"while (a) { for (b in c) { if (b) { break } } }", "while (a) for (b in c) ifb break;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	forIn := parseFirstStatement(t, "for (x in [1, 2]) { x }").(*ast.ForInStatement)
+	testIdentifer(t, forIn.Variable, "x")
+	if _, ok := forIn.Iterable.(*ast.ArrayLiteral); !ok {
+		t.Errorf("forIn.Iterable is not ast.ArrayLiteral. got=%T", forIn.Iterable)
+	}
+}
+
+func parseFirstStatement(t *testing.T, input string) ast.Statement {
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	return program.Statements[0]
+}
+
+func TestBranchOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break is not in a loop"},
+		{"if (x) { continue }", "1:10: continue is not in a loop"},
+		{"while (x) { let f = fn() { break; }; }", "1:28: break is not in a loop"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("expected 1 parser error for %q. got=%v", tt.input, errors)
+		}
+		if errors[0].Error() != tt.expected || errors[0].Code != ErrOutsideLoop {
+			t.Errorf("wrong error. expected=%q, got=%q (%s)", tt.expected, errors[0].Error(), errors[0].Code)
+		}
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5;", "x = 5"},
+		{"x = y = 1 + 2", "x = y = (1 + 2)"},
+		{"a[0] = b || c", "(a[0]) = (b || c)"},
+		{"h[\"k\"][1] = f(x)", "((h[k])[1]) = f(x)"},
+		{"let f = fn() { n = n + 1 }", "let f = fn()n = (n + 1);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	for _, input := range []string{"1 = 2", "f() = 1", "a + b = 1"} {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0].Code != ErrInvalidAssignment {
+			t.Errorf("expected 1 invalid assignment error for %q. got=%v", input, errors)
+		}
+	}
+}
+
+func TestFunctionStatement(t *testing.T) {
+	input := `fn add(x, y) { x + y; } fn(x) { x }(1);`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionStatement. got=%T", program.Statements[0])
+	}
+	testIdentifer(t, stmt.Name, "add")
+	if len(stmt.Function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d", len(stmt.Function.Parameters))
+	}
+	testLiteralExpression(t, stmt.Function.Parameters[0].Name, "x")
+	testLiteralExpression(t, stmt.Function.Parameters[1].Name, "y")
+	if stmt.String() != "fn add(x, y)(x + y)" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+
+	if _, ok := program.Statements[1].(*ast.ExpressionStatement); !ok {
+		t.Errorf("program.Statements[1] is not ast.ExpressionStatement. got=%T", program.Statements[1])
+	}
+}
+
+func TestExtendedParametersAndArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x, y = 2) { x }", "fn(x, y = 2)x"},
+		{"fn(x = a + b, ...rest) { x }", "fn(x = (a + b), ...rest)x"},
+		{"fn(...args) {}", "fn(...args)"},
+		{"fn f(a, b = [1]) { a }", "fn f(a, b = [1])a"},
+		{"f(1, y: 2, z: a * b)", "f(1, y: 2, z: (a * b))"},
+		{"f(x: {1: 2}[1])", "f(x: ({1:2}[1]))"},
+		{"f({a: 1})", "f({a:1})"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input string
+		code  ErrorCode
+		msg   string
+	}{
+		{"fn(...rest, x) {}", ErrUnexpectedToken, "1:11: expected next token to be ), got , instead"},
+		{"fn(1) {}", ErrInvalidParameter, "1:4: expected parameter name, got INT instead"},
+		{"f(x: 1, 2)", ErrInvalidArgument, "1:9: positional argument follows keyword argument"},
+		{"f(x: 1, x: 2)", ErrInvalidArgument, "1:9: duplicate keyword argument x"},
+		{"macro(a = 1) { a }", ErrInvalidParameter, "1:1: macro parameters cannot have default values, patterns or rest parameter"},
+		{"fn(x, x) {}", ErrInvalidParameter, "1:7: duplicate parameter name: x"},
+		{"fn(x, [y, x]) {}", ErrInvalidParameter, "1:11: duplicate parameter name: x"},
+		{"fn({a, b: [c, a]}) {}", ErrInvalidParameter, "1:15: duplicate parameter name: a"},
+		{"fn(x, ...x) {}", ErrInvalidParameter, "1:10: duplicate parameter name: x"},
+		{"fn f(a, b = 1, a) {}", ErrInvalidParameter, "1:16: duplicate parameter name: a"},
+		{"macro(x, x) { x }", ErrInvalidParameter, "1:10: duplicate parameter name: x"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Code != tt.code || errors[0].Error() != tt.msg {
+			t.Errorf("wrong error for %q. expected=%q (%s), got=%q (%s)",
+				tt.input, tt.msg, tt.code, errors[0].Error(), errors[0].Code)
+		}
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b, ...tail] = arr;", "let [a, b, ...tail] = arr;"},
+		{"let [] = arr", "let [] = arr;"},
+		{"let {name, age} = person", "let {name, age} = person;"},
+		{`let {name: n, "first-name": f, pos: [x, y]} = p`, `let {name: n, first-name: f, pos: [x, y]} = p;`},
+		{"const [a, {b}] = c", "const [a, {b}] = c;"},
+		{"fn([a, b], {c} = d, e) { a }", "fn([a, b], {c} = d, e)a"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	stmt := parseFirstStatement(t, "let [a, ...rest] = x").(*ast.LetStatement)
+	if stmt.Name != nil {
+		t.Errorf("stmt.Name should be nil for destructuring. got=%s", stmt.Name)
+	}
+	pattern, ok := stmt.Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("stmt.Pattern is not ast.ArrayPattern. got=%T", stmt.Pattern)
+	}
+	testIdentifer(t, pattern.Elements[0], "a")
+	testIdentifer(t, pattern.Rest, "rest")
+
+	function := parseFirstStatement(t, "fn([a, b], c = 1) { a }").(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function parameters wrong, want 2 got=%d", len(function.Parameters))
+	}
+	if function.Parameters[0].Name != nil {
+		t.Errorf("destructured parameter should have no name. got=%s", function.Parameters[0].Name)
+	}
+	if _, ok := function.Parameters[0].Pattern.(*ast.ArrayPattern); !ok {
+		t.Errorf("parameter pattern is not ast.ArrayPattern. got=%T", function.Parameters[0].Pattern)
+	}
+	testIdentifer(t, function.Parameters[1].Name, "c")
+	if function.Parameters[1].Pattern != nil {
+		t.Errorf("plain parameter should have no pattern. got=%s", function.Parameters[1].Pattern)
+	}
+	testIntegerLiteral(t, function.Parameters[1].Default, 1)
+
+	errorTests := []struct {
+		input string
+		msg   string
+	}{
+		{"let [a, 1] = x", "1:9: expected identifier or destructuring pattern, got INT instead"},
+		{"let [a, a] = x", "1:9: duplicate name in pattern: a"},
+		{"const {a, b: [c, ...a]} = x", "1:21: duplicate name in pattern: a"},
+		{"let [...r, a] = x", "1:10: expected next token to be ], got , instead"},
+		{`let {"k"} = x`, "1:9: expected next token to be :, got } instead"},
+		{"let {1: a} = x", "1:6: expected hash pattern key, got INT instead"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.msg {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.msg, errors[0].Error())
+		}
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1 => a, _ => b }", "match (x) {1 => a, _ => b}"},
+		{"match (x) { -1 => a, 2.5 => b, \"s\" => c, null => d, true => e, false => f }",
+			"match (x) {(-1) => a, 2.5 => b, s => c, null => d, true => e, false => f}"},
+		{"match (f(x)) { [a, ...rest] => a, {name, age: n} => n + 1, }",
+			"match (f(x)) {[a, ...rest] => a, {name, age: n} => (n + 1)}"},
+		{"match (x) { n: INTEGER if n > 0 => n, {k: v: STRING} => v }",
+			"match (x) {n: INTEGER if (n > 0) => n, {k: v: STRING} => v}"},
+		{"match (x) {}", "match (x) {}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	stmt := parseFirstStatement(t, "match (x) { n if n > 1 => 1 }").(*ast.ExpressionStatement)
+	match, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+	}
+	if len(match.Arms) != 1 {
+		t.Fatalf("match.Arms has wrong length. got=%d", len(match.Arms))
+	}
+	testIdentifer(t, match.Arms[0].Pattern, "n")
+	testInfixExpression(t, match.Arms[0].Guard, "n", ">", 1)
+	testIntegerLiteral(t, match.Arms[0].Body, 1)
+
+	errorTests := []struct {
+		input string
+		msg   string
+	}{
+		{"match (x) { a + b => 1 }", "1:15: expected next token to be =>, got + instead"},
+		{"match (x) { (a) => 1 }", "1:13: expected match pattern, got ( instead"},
+		{"match (x) { 1 => 1 2 => 2 }", "1:20: expected next token to be ,, got INT instead"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.msg {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.msg, errors[0].Error())
+		}
+	}
+}
+
+func TestTryAndThrowStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { f(); } catch (e) { g(e); }", "try f() catch (e) g(e)"},
+		{"try { f() } finally { g() }", "try f() finally g()"},
+		{"try { f() } catch (err) { 1 } finally { 2 }; x", "try f() catch (err) 1 finally 2x"},
+		{"throw 1 + 2; throw e", "throw (1 + 2);throw e;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	stmt, ok := parseFirstStatement(t, "try { 1 } catch (e) { 2 }").(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.TryStatement")
+	}
+	testIdentifer(t, stmt.Param, "e")
+	if stmt.Finally != nil {
+		t.Errorf("stmt.Finally is not nil. got=%s", stmt.Finally)
+	}
+
+	errorTests := []struct {
+		input string
+		msg   string
+	}{
+		{"try { 1 } x", "1:11: expected catch or finally after try block, got IDENT instead"},
+		{"try { 1 } catch { 2 }", "1:17: expected next token to be (, got { instead"},
+		{"try { 1 } catch (1) { 2 }", "1:18: expected next token to be IDENT, got INT instead"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.msg {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.msg, errors[0].Error())
+		}
+	}
+}
+
+func TestImportAndExport(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let m = import "lib/math";`, `let m = import "lib/math";`},
+		{`import "./util"["f"](1)`, `(import "./util"[f])(1)`},
+		{"export let x = 1; export const y = 2;", "export let x = 1;export const y = 2;"},
+		{"export let [a, b] = pair;", "export let [a, b] = pair;"},
+		{"export fn f(x) { x }", "export fn f(x)x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input string
+		msg   string
+		code  ErrorCode
+	}{
+		{"import lib", "1:8: expected next token to be STRING, got IDENT instead", ErrUnexpectedToken},
+		{"export 1", "1:8: expected let, const or function declaration after export, got INT instead", ErrInvalidExport},
+		{"export fn(x) { x }", "1:8: expected let, const or function declaration after export, got FUNCTION instead", ErrInvalidExport},
+		{"fn f() { export let x = 1; }", "1:10: export is only allowed at the top level", ErrInvalidExport},
+		{"if (true) { export fn g() { 1 } }", "1:13: export is only allowed at the top level", ErrInvalidExport},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.msg || errors[0].Code != tt.code {
+			t.Errorf("wrong error for %q. expected=%q (%s), got=%q (%s)", tt.input, tt.msg, tt.code, errors[0].Error(), errors[0].Code)
+		}
+	}
+}
+
+func TestMemberExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.b", "(a.b)"},
+		{"a.b.c", "((a.b).c)"},
+		{"a.push(1)", "(a.push)(1)"},
+		{"a.b[0].c()", "(((a.b)[0]).c)()"},
+		{"-a.b ** 2", "(-((a.b) ** 2))"},
+		{"f(x).y + 1", "((f(x).y) + 1)"},
+		{"h.name = 1 + 2", "(h.name) = (1 + 2)"},
+		{`"abc".upper()`, "(abc.upper)()"},
+		{`(import "lib").f`, `(import "lib".f)`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	stmt := parseFirstStatement(t, "obj.field").(*ast.ExpressionStatement)
+	member, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MemberExpression. got=%T", stmt.Expression)
+	}
+	testIdentifer(t, member.Object, "obj")
+	testIdentifer(t, member.Property, "field")
+	if member.Pos().Column != 1 || member.End().Column != 10 {
+		t.Errorf("wrong range. got=%s-%s", member.Pos(), member.End())
+	}
+
+	errorTests := []struct {
+		input string
+		msg   string
+	}{
+		{"a.1", "1:3: expected next token to be IDENT, got INT instead"},
+		{"a.", "1:3: expected next token to be IDENT, got EOF instead"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.msg {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.msg, errors[0].Error())
+		}
+	}
+}
+
+func TestNonExhaustiveMatchWarning(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { true => 1 }", "1:1: match is not exhaustive: missing false"},
+		{"match (x) { true => 1, false if y => 2 }", "1:1: match is not exhaustive: missing false"},
+		{"let a = 1;\nmatch (x) { null => 1 }", "2:1: match is not exhaustive: missing non-null values"},
+		{"match (x) { true => 1, false => 0 }", ""},
+		{"match (x) { true => 1, _ => 0 }", ""},
+		{"match (x) { null => 0, _: BOOLEAN => 1 }", ""},
+		{"match (x) { 1 => 0 }", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		warnings := p.Warnings()
+		if tt.expected == "" {
+			if len(warnings) != 0 {
+				t.Errorf("unexpected warnings for %q: %v", tt.input, warnings)
+			}
+			continue
+		}
+		if len(warnings) != 1 {
+			t.Errorf("expected 1 warning for %q. got=%v", tt.input, warnings)
+			continue
+		}
+		if warnings[0].Error() != tt.expected || warnings[0].Code != WarnNonExhaustiveMatch {
+			t.Errorf("wrong warning. expected=%q, got=%q (%s)", tt.expected, warnings[0].Error(), warnings[0].Code)
+		}
+	}
+}
+
+func TestNodePosition(t *testing.T) {
+	input := "let x = 1;\nadd(x, [1, 2]) + y[0];"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[1].(*ast.ExpressionStatement)
+	if pos := stmt.Pos().String(); pos != "2:1" {
+		t.Errorf("stmt.Pos() wrong. expected=%q, got=%q", "2:1", pos)
+	}
+	if end := stmt.End().String(); end != "2:22" {
+		t.Errorf("stmt.End() wrong. expected=%q, got=%q", "2:22", end)
+	}
+
+	infix := stmt.Expression.(*ast.InfixExpression)
+	call := infix.Left.(*ast.CallExpression)
+	if end := call.End().String(); end != "2:15" {
+		t.Errorf("call.End() wrong. expected=%q, got=%q", "2:15", end)
+	}
+	if pos := call.Arguments[1].Pos().String(); pos != "2:8" {
+		t.Errorf("array.Pos() wrong. expected=%q, got=%q", "2:8", pos)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `
+	// add two numbers
+	let add = fn(x, y) {
+		x + y; /* the result */
+	};
+	add(1, 2) // call it
+	`
+
+	l := lexer.New(input)
+	l.SetMode(lexer.ScanComments)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	expected := []string{"// add two numbers", "/* the result */", "// call it"}
+	if len(program.Comments) != len(expected) {
+		t.Fatalf("program.Comments has wrong length. want=%d, got=%d",
+			len(expected), len(program.Comments))
+	}
+	for i, c := range program.Comments {
+		if c.String() != expected[i] {
+			t.Errorf("comment[%d] wrong. want=%q, got=%q", i, expected[i], c.String())
+		}
+	}
+	if pos := program.Comments[1].Pos().String(); pos != "4:10" {
+		t.Errorf("comment position wrong. want=%q, got=%q", "4:10", pos)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := lexer.New("let x = 1;\n/* oops")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error. got=%d (%v)", len(errors), errors)
+	}
+	if errors[0].Error() != "2:1: comment not terminated" {
+		t.Errorf("wrong error. got=%q", errors[0].Error())
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	l := lexer.New("let a = 1;\nlet s = \"hello;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+	if errors[0].Error() != "2:9: string literal not terminated" {
+		t.Errorf("wrong error. got=%q", errors[0].Error())
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5;", 3.5},
+		{"1e3;", 1000},
+		{"2.5e-1;", 0.25},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statement[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestInterpolatedString(t *testing.T) {
+	input := `"hello ${name}, ${1 + 2} items"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statement[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	expectedStrings := []string{"hello ", ", ", " items"}
+	if len(str.Strings) != len(expectedStrings) {
+		t.Fatalf("str.Strings wrong. expected=%q, got=%q", expectedStrings, str.Strings)
+	}
+	for i, s := range expectedStrings {
+		if str.Strings[i] != s {
+			t.Errorf("str.Strings[%d] wrong. expected=%q, got=%q", i, s, str.Strings[i])
+		}
+	}
+
+	if len(str.Parts) != 2 {
+		t.Fatalf("str.Parts has wrong length. got=%d", len(str.Parts))
+	}
+	testIdentifer(t, str.Parts[0], "name")
+	testInfixExpression(t, str.Parts[1], 1, "+", 2)
+
+	if str.End().Offset != len(input) {
+		t.Errorf("str.End() wrong. expected offset %d, got=%d", len(input), str.End().Offset)
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xff", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"9223372036854775807", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %d. got=%d", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestInvalidIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a =\n 9223372036854775808;", "2:2: integer literal 9223372036854775808 overflows int64"},
+		{"0xfg", `1:1: cloud not parse "0xfg" as integer`},
+		{"1__000", `1:1: cloud not parse "1__000" as integer`},
+		{"0b102", `1:1: cloud not parse "0b102" as integer`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("expected 1 parser error for %q. got=%v", tt.input, errors)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+	}
+}
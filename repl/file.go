@@ -0,0 +1,63 @@
+package repl
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fengshux/monkey/evaluator"
+	"github.com/fengshux/monkey/lexer"
+	"github.com/fengshux/monkey/object"
+	"github.com/fengshux/monkey/parser"
+)
+
+var (
+	ErrParse   = errors.New("monkey: parse failed")
+	ErrRuntime = errors.New("monkey: runtime error")
+)
+
+// SearchPath 返回查找模块的目录列表：dir 之后是环境变量 MONKEYPATH 中列出的目录
+func SearchPath(dir string) []string {
+	return append([]string{dir}, filepath.SplitList(os.Getenv("MONKEYPATH"))...)
+}
+
+// RunFile 执行一个Monkey脚本文件
+func RunFile(filename string, out io.Writer) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Run(filename, f, out)
+}
+
+// Run 执行从in中读取的Monkey程序，错误信息写入out。
+// 函数声明会提升到所在作用域的开头，宏也要在求值之前展开，所以程序需要完整解析之后才开始求值。
+// 源代码先整个读入，再交给比 lexer.NewReader 更快的 lexer.NewFile
+func Run(filename string, in io.Reader, out io.Writer) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	l := lexer.NewFile(filename, string(src))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return ErrParse
+	}
+	printParserWarnings(out, p.Warnings())
+
+	env := object.NewEnvironment()
+	env.SetImporter(evaluator.NewFileImporter(SearchPath(filepath.Dir(filename))...))
+	macroEnv := object.NewEnvironment()
+	evaluated := evaluator.EvalProgram(program, env, macroEnv)
+	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
+		io.WriteString(out, evaluated.Inspect())
+		io.WriteString(out, "\n")
+		return ErrRuntime
+	}
+	return nil
+}
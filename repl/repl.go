@@ -29,6 +29,7 @@ const PROMPT = ">>"
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	env.SetImporter(evaluator.NewFileImporter(SearchPath(".")...))
 	macroEnv := object.NewEnvironment()
 	for {
 		fmt.Fprint(out, PROMPT)
@@ -45,10 +46,9 @@ func Start(in io.Reader, out io.Writer) {
 			printParserErrors(out, p.Errors())
 			continue
 		}
+		printParserWarnings(out, p.Warnings())
 
-		evaluator.DefineMacros(program, macroEnv)
-		expanded := evaluator.ExpandMacros(program, macroEnv)
-		evaluated := evaluator.Eval(expanded, env)
+		evaluated := evaluator.EvalProgram(program, env, macroEnv)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
@@ -56,11 +56,17 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
+func printParserErrors(out io.Writer, errors parser.ErrorList) {
 	io.WriteString(out, MONKEY_FACE)
 	io.WriteString(out, "Woops! We ran into some monkey business here!\n")
 	io.WriteString(out, " parser errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+	for _, err := range errors {
+		io.WriteString(out, "\t"+err.Error()+"\n")
+	}
+}
+
+func printParserWarnings(out io.Writer, warnings parser.ErrorList) {
+	for _, w := range warnings {
+		io.WriteString(out, "warning: "+w.Error()+"\n")
 	}
 }
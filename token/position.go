@@ -0,0 +1,29 @@
+package token
+
+import "fmt"
+
+// Position 描述源代码中的一个位置，Line和Column从1开始计数
+type Position struct {
+	Filename string `json:"filename,omitempty"`
+	Offset   int    `json:"offset"` // 字节偏移量，从0开始
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String 返回 file:line:column 格式的位置，没有文件名时返回 line:column
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
@@ -1,10 +1,42 @@
 package token
 
+import "encoding/json"
+
 type TokenType string
 
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Pos     Position  `json:"pos"` // 词法单元第一个字符的位置
+	Raw     string    `json:"-"`   // 与 Literal 不同时（如带引号和转义的字符串）保存词法单元的原文
+}
+
+// MarshalJSON 把 End 推算出的位置一起输出
+func (t Token) MarshalJSON() ([]byte, error) {
+	type plain Token
+	return json.Marshal(struct {
+		plain
+		End Position `json:"end"`
+	}{plain(t), t.End()})
+}
+
+// End 返回词法单元最后一个字符之后的位置，由 Pos 和词法单元的原文推算
+func (t Token) End() Position {
+	text := t.Literal
+	if t.Raw != "" {
+		text = t.Raw
+	}
+	end := t.Pos
+	end.Offset += len(text)
+	for _, r := range text {
+		if r == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+	return end
 }
 
 const (
@@ -14,25 +46,51 @@ const (
 	// 标识符+字面量
 	IDENT  = "IDENT" // add, foobar, x, y, ...
 	INT    = "INT"   // 1343456
+	FLOAT  = "FLOAT" // 3.14, 1e10, 2.5e-3
 	STRING = "STRING"
 
+	// 插值字符串 "a ${x} b ${y} c" 被分成 INTERP_HEAD("a ")、x、INTERP_MID(" b ")、y、INTERP_TAIL(" c")
+	INTERP_HEAD = "INTERP_HEAD"
+	INTERP_MID  = "INTERP_MID"
+	INTERP_TAIL = "INTERP_TAIL"
+
+	// 注释，只有在词法分析器开启 ScanComments 模式时才会产生
+	COMMENT = "COMMENT"
+
 	// 运算符
 	ASSIGN   = "="
 	PLUS     = "+"
 	MINUS    = "-"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POWER    = "**"
+
+	BIT_AND = "&"
+	BIT_OR  = "|"
+	BIT_XOR = "^"
+	BIT_NOT = "~"
+	SHL     = "<<"
+	SHR     = ">>"
 
 	BANG   = "!"
 	LT     = "<"
 	GT     = ">"
 	EQ     = "=="
 	NOT_EQ = "!="
+	LT_EQ  = "<="
+	GT_EQ  = ">="
+
+	AND = "&&"
+	OR  = "||"
 
 	// 分隔符
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	ELLIPSIS  = "..."
+	DOT       = "."
+	ARROW     = "=>"
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -44,23 +102,51 @@ const (
 	// 关键字
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
 	MACRO    = "MACRO"
+	NULL     = "NULL"
+	MATCH    = "MATCH"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"macro":  MACRO,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"const":    CONST,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"macro":    MACRO,
+	"null":     NULL,
+	"match":    MATCH,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"export":   EXPORT,
 }
 
 func LookupIdent(ident string) TokenType {